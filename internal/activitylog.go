@@ -0,0 +1,112 @@
+package internal
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	activityLogAPIVersion = "2015-04-01"
+
+	// maxActivityLogDays is how far back the Activity Log retains events.
+	maxActivityLogDays = 90
+)
+
+// ActivityLogOperation is an administrative operation on a server recorded in the Activity Log, such as a
+// configuration change, firewall rule edit or restart.
+type ActivityLogOperation struct {
+	// Operation is the operation name, e.g. Microsoft.DBforPostgreSQL/flexibleServers/firewallRules/write.
+	Operation     string `json:"operation"`
+	OperationText string `json:"operation_text,omitempty"`
+	// Status is e.g. Started, Accepted, Succeeded or Failed.
+	Status        string     `json:"status,omitempty"`
+	Caller        string     `json:"caller,omitempty"`
+	Timestamp     *time.Time `json:"timestamp,omitempty"`
+	CorrelationID string     `json:"correlation_id,omitempty"`
+	ResourceID    string     `json:"resource_id,omitempty"`
+}
+
+type armLocalizableString struct {
+	Value          *string `json:"value"`
+	LocalizedValue *string `json:"localizedValue"`
+}
+
+type armActivityLogEvent struct {
+	OperationName  *armLocalizableString `json:"operationName"`
+	Category       *armLocalizableString `json:"category"`
+	Status         *armLocalizableString `json:"status"`
+	Caller         *string               `json:"caller"`
+	EventTimestamp *time.Time            `json:"eventTimestamp"`
+	CorrelationID  *string               `json:"correlationId"`
+	ResourceID     *string               `json:"resourceId"`
+}
+
+// GetActivityLogOperations lists the administrative operations on a server over the last days, newest first.
+func (dp *AzureDataProcessor) GetActivityLogOperations(serverID string, days int) ([]ActivityLogOperation, error) {
+	client, err := dp.getARMClient()
+	if err != nil {
+		return nil, err
+	}
+	idparts, err := ParseResourceID(serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	end := time.Now().UTC()
+	start := end.AddDate(0, 0, -days)
+	filter := fmt.Sprintf("eventTimestamp ge '%s' and eventTimestamp le '%s' and resourceUri eq '%s'", start.Format(time.RFC3339), end.Format(time.RFC3339), serverID)
+	path := fmt.Sprintf("/subscriptions/%s/providers/Microsoft.Insights/eventtypes/management/values?$filter=%s", idparts.SubscriptionID(), url.QueryEscape(filter))
+
+	operations := make([]ActivityLogOperation, 0)
+	for event, err := range ListARMResources[armActivityLogEvent](dp.ctx, client, path, activityLogAPIVersion) {
+		if err != nil {
+			return nil, err
+		}
+		// The management event type also carries Azure Policy, alert and service health events.
+		if event.Category == nil || event.Category.Value == nil || !strings.EqualFold(*event.Category.Value, "Administrative") {
+			continue
+		}
+
+		operation := ActivityLogOperation{
+			Timestamp: event.EventTimestamp,
+		}
+		if event.OperationName != nil {
+			if event.OperationName.Value != nil {
+				operation.Operation = *event.OperationName.Value
+			}
+			if event.OperationName.LocalizedValue != nil {
+				operation.OperationText = *event.OperationName.LocalizedValue
+			}
+		}
+		if event.Status != nil && event.Status.Value != nil {
+			operation.Status = *event.Status.Value
+		}
+		if event.Caller != nil {
+			operation.Caller = *event.Caller
+		}
+		if event.CorrelationID != nil {
+			operation.CorrelationID = *event.CorrelationID
+		}
+		if event.ResourceID != nil {
+			operation.ResourceID = *event.ResourceID
+		}
+		operations = append(operations, operation)
+	}
+	return operations, nil
+}
+
+// LastChange returns the time of the newest succeeded operation, or nil when there is none.
+func LastChange(operations []ActivityLogOperation) *time.Time {
+	var last *time.Time
+	for _, operation := range operations {
+		if operation.Timestamp == nil || !strings.EqualFold(operation.Status, "Succeeded") {
+			continue
+		}
+		if last == nil || operation.Timestamp.After(*last) {
+			last = operation.Timestamp
+		}
+	}
+	return last
+}
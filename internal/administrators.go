@@ -0,0 +1,94 @@
+package internal
+
+import (
+	"strings"
+)
+
+// AuthConfig records which authentication methods a server accepts.
+type AuthConfig struct {
+	// ActiveDirectoryAuth and PasswordAuth are Enabled or Disabled.
+	ActiveDirectoryAuth string `json:"active_directory_auth"`
+	PasswordAuth        string `json:"password_auth"`
+	TenantID            string `json:"tenant_id,omitempty"`
+}
+
+// NewAuthConfig reads the authentication config from the extended server. It returns nil when the extended server
+// could not be fetched or has no authentication config.
+func NewAuthConfig(extended *ExtendedServer) *AuthConfig {
+	if extended == nil || extended.Properties == nil || extended.Properties.AuthConfig == nil {
+		return nil
+	}
+
+	authConfig := &AuthConfig{}
+	if extended.Properties.AuthConfig.ActiveDirectoryAuth != nil {
+		authConfig.ActiveDirectoryAuth = *extended.Properties.AuthConfig.ActiveDirectoryAuth
+	}
+	if extended.Properties.AuthConfig.PasswordAuth != nil {
+		authConfig.PasswordAuth = *extended.Properties.AuthConfig.PasswordAuth
+	}
+	if extended.Properties.AuthConfig.TenantID != nil {
+		authConfig.TenantID = *extended.Properties.AuthConfig.TenantID
+	}
+	return authConfig
+}
+
+// Administrator is a Microsoft Entra ID principal configured as an administrator of a server.
+type Administrator struct {
+	Name          string `json:"name"`
+	PrincipalName string `json:"principal_name"`
+	PrincipalType string `json:"principal_type"`
+	ObjectID      string `json:"object_id"`
+	TenantID      string `json:"tenant_id"`
+}
+
+type armAdministrator struct {
+	Name       *string `json:"name"`
+	Properties *struct {
+		PrincipalName *string `json:"principalName"`
+		PrincipalType *string `json:"principalType"`
+		ObjectID      *string `json:"objectId"`
+		TenantID      *string `json:"tenantId"`
+	} `json:"properties"`
+}
+
+// GetAdministrators lists the Microsoft Entra ID administrators of a server. A server without administrators,
+// including one that only accepts password authentication, returns an empty list.
+func (dp *AzureDataProcessor) GetAdministrators(serverID string) ([]Administrator, error) {
+	client, err := dp.getARMClient()
+	if err != nil {
+		return nil, err
+	}
+
+	administrators := make([]Administrator, 0)
+	for administrator, err := range ListARMResources[armAdministrator](dp.ctx, client, serverID+"/administrators", flexibleServersAPIVersion) {
+		if err != nil {
+			return nil, err
+		}
+
+		a := Administrator{}
+		if administrator.Name != nil {
+			a.Name = *administrator.Name
+		}
+		if properties := administrator.Properties; properties != nil {
+			if properties.PrincipalName != nil {
+				a.PrincipalName = *properties.PrincipalName
+			}
+			if properties.PrincipalType != nil {
+				a.PrincipalType = *properties.PrincipalType
+			}
+			if properties.ObjectID != nil {
+				a.ObjectID = *properties.ObjectID
+			}
+			if properties.TenantID != nil {
+				a.TenantID = *properties.TenantID
+			}
+		}
+		administrators = append(administrators, a)
+	}
+	return administrators, nil
+}
+
+// IsAuthEnabled reports whether an authentication method's state is Enabled.
+func IsAuthEnabled(state string) bool {
+	return strings.EqualFold(state, "Enabled")
+}
@@ -0,0 +1,100 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const advisorAPIVersion = "2023-01-01"
+
+// AdvisorRecommendation is an active Azure Advisor recommendation for a server.
+type AdvisorRecommendation struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Category is Cost, HighAvailability, OperationalExcellence, Performance or Security.
+	Category string `json:"category,omitempty"`
+	// Impact is High, Medium or Low.
+	Impact               string     `json:"impact,omitempty"`
+	Problem              string     `json:"problem,omitempty"`
+	Solution             string     `json:"solution,omitempty"`
+	RecommendationTypeID string     `json:"recommendation_type_id,omitempty"`
+	LastUpdated          *time.Time `json:"last_updated,omitempty"`
+}
+
+type armAdvisorRecommendation struct {
+	ID         *string `json:"id"`
+	Name       *string `json:"name"`
+	Properties *struct {
+		Category         *string `json:"category"`
+		Impact           *string `json:"impact"`
+		ShortDescription *struct {
+			Problem  *string `json:"problem"`
+			Solution *string `json:"solution"`
+		} `json:"shortDescription"`
+		RecommendationTypeID *string    `json:"recommendationTypeId"`
+		LastUpdated          *time.Time `json:"lastUpdated"`
+		SuppressionIDs       []*string  `json:"suppressionIds"`
+	} `json:"properties"`
+}
+
+// GetAdvisorRecommendations lists the active Advisor recommendations for a server. Recommendations that have been
+// postponed or dismissed are left out.
+func (dp *AzureDataProcessor) GetAdvisorRecommendations(serverID string) ([]AdvisorRecommendation, error) {
+	client, err := dp.getARMClient()
+	if err != nil {
+		return nil, err
+	}
+
+	recommendations := make([]AdvisorRecommendation, 0)
+	path := fmt.Sprintf("%s/providers/Microsoft.Advisor/recommendations", serverID)
+	for recommendation, err := range ListARMResources[armAdvisorRecommendation](dp.ctx, client, path, advisorAPIVersion) {
+		if err != nil {
+			return nil, err
+		}
+
+		r := AdvisorRecommendation{}
+		if recommendation.ID != nil {
+			r.ID = *recommendation.ID
+		}
+		if recommendation.Name != nil {
+			r.Name = *recommendation.Name
+		}
+		if properties := recommendation.Properties; properties != nil {
+			if len(properties.SuppressionIDs) > 0 {
+				continue
+			}
+			if properties.Category != nil {
+				r.Category = *properties.Category
+			}
+			if properties.Impact != nil {
+				r.Impact = *properties.Impact
+			}
+			if description := properties.ShortDescription; description != nil {
+				if description.Problem != nil {
+					r.Problem = *description.Problem
+				}
+				if description.Solution != nil {
+					r.Solution = *description.Solution
+				}
+			}
+			if properties.RecommendationTypeID != nil {
+				r.RecommendationTypeID = *properties.RecommendationTypeID
+			}
+			r.LastUpdated = properties.LastUpdated
+		}
+		recommendations = append(recommendations, r)
+	}
+	return recommendations, nil
+}
+
+// HighImpactRecommendations returns the recommendations Advisor rates as high impact.
+func HighImpactRecommendations(recommendations []AdvisorRecommendation) []AdvisorRecommendation {
+	high := make([]AdvisorRecommendation, 0)
+	for _, recommendation := range recommendations {
+		if strings.EqualFold(recommendation.Impact, "High") {
+			high = append(high, recommendation)
+		}
+	}
+	return high
+}
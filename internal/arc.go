@@ -0,0 +1,111 @@
+package internal
+
+import (
+	"context"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/postgresql/armpostgresqlflexibleservers"
+)
+
+// arcPostgresAPIVersion is the ARM API version of the Microsoft.AzureArcData/postgresInstances resource, which
+// projects PostgreSQL instances run by Azure Arc data services on Kubernetes into Azure.
+const arcPostgresAPIVersion = "2023-01-15-preview"
+
+// ArcProperties are the Azure Arc-enabled PostgreSQL instance settings in the policy input.
+type ArcProperties struct {
+	DataControllerID string `json:"data_controller_id,omitempty"`
+	// CustomLocationID is the custom location, and so the Kubernetes cluster, the instance runs in.
+	CustomLocationID  string `json:"custom_location_id,omitempty"`
+	Admin             string `json:"admin,omitempty"`
+	ProvisioningState string `json:"provisioning_state,omitempty"`
+	// LastUploadedDate is when the data controller last uploaded the instance's state to Azure, so policies can
+	// flag instances whose state is stale.
+	LastUploadedDate *time.Time `json:"last_uploaded_date,omitempty"`
+	// SKUTier is Dev or Production.
+	SKUTier string `json:"sku_tier,omitempty"`
+}
+
+// ArcInstance is the subset of the Arc-enabled PostgreSQL resource that the flexible server SDK model doesn't carry.
+type ArcInstance struct {
+	ExtendedLocation *struct {
+		Name *string `json:"name,omitempty"`
+	} `json:"extendedLocation,omitempty"`
+	SKU *struct {
+		Tier *string `json:"tier,omitempty"`
+	} `json:"sku,omitempty"`
+	Properties *struct {
+		DataControllerID  *string    `json:"dataControllerId,omitempty"`
+		Admin             *string    `json:"admin,omitempty"`
+		ProvisioningState *string    `json:"provisioningState,omitempty"`
+		LastUploadedDate  *time.Time `json:"lastUploadedDate,omitempty"`
+	} `json:"properties,omitempty"`
+}
+
+// GetArcInstance fetches an Arc-enabled PostgreSQL instance through ARM.
+func (dp *AzureDataProcessor) GetArcInstance(instanceID string) (*ArcInstance, error) {
+	client, err := dp.getARMClient()
+	if err != nil {
+		return nil, err
+	}
+
+	instance := &ArcInstance{}
+	if err := client.Get(dp.ctx, instanceID, arcPostgresAPIVersion, instance); err != nil {
+		return nil, err
+	}
+	return instance, nil
+}
+
+// NewArcProperties extracts the Arc-enabled instance settings for the policy input.
+func NewArcProperties(instance *ArcInstance) *ArcProperties {
+	properties := &ArcProperties{}
+	if instance.ExtendedLocation != nil && instance.ExtendedLocation.Name != nil {
+		properties.CustomLocationID = *instance.ExtendedLocation.Name
+	}
+	if instance.SKU != nil && instance.SKU.Tier != nil {
+		properties.SKUTier = *instance.SKU.Tier
+	}
+	if source := instance.Properties; source != nil {
+		if source.DataControllerID != nil {
+			properties.DataControllerID = *source.DataControllerID
+		}
+		if source.Admin != nil {
+			properties.Admin = *source.Admin
+		}
+		if source.ProvisioningState != nil {
+			properties.ProvisioningState = *source.ProvisioningState
+		}
+		properties.LastUploadedDate = source.LastUploadedDate
+	}
+	return properties
+}
+
+// listArcServers yields the Arc-enabled PostgreSQL instances of a subscription, or of one of its resource groups
+// when set. The instance resource is decoded into the flexible server SDK model, which keeps its ID, name, location
+// and tags. It returns false once the consumer stops iterating.
+func (l *AzureServerLister) listArcServers(ctx context.Context, client *ARMClient, subscriptionID string, resourceGroup string, yield func(*armpostgresqlflexibleservers.Server, error) bool) bool {
+	path := "/subscriptions/" + subscriptionID
+	if resourceGroup != "" {
+		path += "/resourceGroups/" + resourceGroup
+	}
+	path += "/providers/Microsoft.AzureArcData/postgresInstances"
+
+	for instance, err := range ListARMResources[armpostgresqlflexibleservers.Server](ctx, client, path, arcPostgresAPIVersion) {
+		if err != nil {
+			l.logger.Error("unable to list Azure Arc-enabled PostgreSQL instances", "subscription", subscriptionID, "resource_group", resourceGroup, "error", err)
+			return yield(nil, &CollectionError{
+				Operation:      "list arc servers",
+				SubscriptionID: subscriptionID,
+				ResourceGroup:  resourceGroup,
+				Err:            err,
+				Fatal:          IsFatalError(err),
+			})
+		}
+		if instance.ID == nil || ServerTypeOf(*instance.ID) != ServerTypeArc {
+			continue
+		}
+		if !yield(&instance, nil) {
+			return false
+		}
+	}
+	return true
+}
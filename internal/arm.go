@@ -0,0 +1,127 @@
+package internal
+
+import (
+	"context"
+	"iter"
+	"net/http"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+)
+
+const (
+	armModuleName    = "github.com/compliance-framework/plugin-azure-db-psql"
+	armModuleVersion = "v0.0.0"
+
+	// flexibleServersAPIVersion is the ARM API version used for flexible server data that the
+	// armpostgresqlflexibleservers SDK (API version 2021-06-01) does not expose.
+	flexibleServersAPIVersion = "2024-08-01"
+)
+
+// ARMClient issues raw requests against Azure Resource Manager for resources, or API versions of
+// resources, that are not covered by the Azure SDK packages the plugin depends on.
+type ARMClient struct {
+	client *arm.Client
+}
+
+func NewARMClient(cred azcore.TokenCredential, options *arm.ClientOptions) (*ARMClient, error) {
+	client, err := arm.NewClient(armModuleName, armModuleVersion, cred, options)
+	if err != nil {
+		return nil, err
+	}
+	return &ARMClient{client: client}, nil
+}
+
+// Get fetches a single ARM resource by its ID and decodes the JSON body into out.
+func (c *ARMClient) Get(ctx context.Context, resourceID string, apiVersion string, out any) error {
+	return c.do(ctx, http.MethodGet, runtime.JoinPaths(c.client.Endpoint(), resourceID), apiVersion, out)
+}
+
+// Post invokes an ARM action, such as a query, that takes no request body and decodes the JSON result into out.
+func (c *ARMClient) Post(ctx context.Context, path string, apiVersion string, out any) error {
+	return c.do(ctx, http.MethodPost, runtime.JoinPaths(c.client.Endpoint(), path), apiVersion, out)
+}
+
+// do sends a request to an absolute endpoint, which may already carry its api-version.
+func (c *ARMClient) do(ctx context.Context, method string, endpoint string, apiVersion string, out any) error {
+	req, err := c.newRequest(ctx, method, endpoint, apiVersion)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.client.Pipeline().Do(req)
+	if err != nil {
+		return err
+	}
+	if !runtime.HasStatusCode(resp, http.StatusOK) {
+		return runtime.NewResponseError(resp)
+	}
+	return runtime.UnmarshalAsJSON(resp, out)
+}
+
+func (c *ARMClient) newRequest(ctx context.Context, method string, endpoint string, apiVersion string) (*policy.Request, error) {
+	req, err := runtime.NewRequest(ctx, method, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if apiVersion != "" {
+		reqQP := req.Raw().URL.Query()
+		reqQP.Set("api-version", apiVersion)
+		req.Raw().URL.RawQuery = reqQP.Encode()
+	}
+	req.Raw().Header["Accept"] = []string{"application/json"}
+	return req, nil
+}
+
+type armListPage[T any] struct {
+	Value    []T     `json:"value"`
+	NextLink *string `json:"nextLink,omitempty"`
+}
+
+// ListARMResources pages through an ARM list endpoint, following nextLink until every item has been yielded.
+func ListARMResources[T any](ctx context.Context, c *ARMClient, path string, apiVersion string) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		endpoint := runtime.JoinPaths(c.client.Endpoint(), path)
+		version := apiVersion
+
+		for endpoint != "" {
+			req, err := c.newRequest(ctx, http.MethodGet, endpoint, version)
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+
+			resp, err := c.client.Pipeline().Do(req)
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+			if !runtime.HasStatusCode(resp, http.StatusOK) {
+				yield(zero, runtime.NewResponseError(resp))
+				return
+			}
+
+			page := armListPage[T]{}
+			if err := runtime.UnmarshalAsJSON(resp, &page); err != nil {
+				yield(zero, err)
+				return
+			}
+
+			for _, item := range page.Value {
+				if !yield(item, nil) {
+					return
+				}
+			}
+
+			endpoint = ""
+			if page.NextLink != nil {
+				// The next link already carries the api-version and any continuation token.
+				endpoint = *page.NextLink
+				version = ""
+			}
+		}
+	}
+}
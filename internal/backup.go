@@ -0,0 +1,168 @@
+package internal
+
+import (
+	"sort"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/postgresql/armpostgresqlflexibleservers"
+)
+
+// backupUnknown is reported for backup settings the server doesn't report, e.g. on older server versions.
+const backupUnknown = "unknown"
+
+// BackupConfig is a server's backup configuration in the policy input.
+type BackupConfig struct {
+	// RetentionDays is null when unknown.
+	RetentionDays *int32 `json:"retention_days"`
+	// GeoRedundantBackup is Enabled, Disabled or unknown.
+	GeoRedundantBackup  string     `json:"geo_redundant_backup"`
+	EarliestRestoreDate *time.Time `json:"earliest_restore_date,omitempty"`
+	// Backups lists the available automated and on-demand backups, newest first, when they could be listed.
+	Backups []AvailableBackup `json:"backups,omitempty"`
+	// LatestBackup is the completion time of the newest backup, so policies can require a recent one.
+	LatestBackup *time.Time `json:"latest_backup,omitempty"`
+	// LongTermRetention lists the long-term retention backup operations, newest first, when collect_ltr_backups is set.
+	LongTermRetention []LTRBackupOperation `json:"long_term_retention,omitempty"`
+}
+
+// LTRBackupOperation is a long-term retention backup of a server to an Azure Backup vault.
+type LTRBackupOperation struct {
+	Name       string `json:"name"`
+	BackupName string `json:"backup_name,omitempty"`
+	// Status is e.g. Succeeded, Running, Failed or Cancelled.
+	Status       string     `json:"status,omitempty"`
+	StartTime    *time.Time `json:"start_time,omitempty"`
+	EndTime      *time.Time `json:"end_time,omitempty"`
+	ErrorMessage string     `json:"error_message,omitempty"`
+}
+
+type armLTRBackupOperation struct {
+	Name       *string `json:"name"`
+	Properties *struct {
+		BackupName   *string    `json:"backupName"`
+		Status       *string    `json:"status"`
+		StartTime    *time.Time `json:"startTime"`
+		EndTime      *time.Time `json:"endTime"`
+		ErrorMessage *string    `json:"errorMessage"`
+	} `json:"properties"`
+}
+
+// GetLTRBackupOperations lists the long-term retention backup operations of a server, newest first.
+func (dp *AzureDataProcessor) GetLTRBackupOperations(serverID string) ([]LTRBackupOperation, error) {
+	client, err := dp.getARMClient()
+	if err != nil {
+		return nil, err
+	}
+
+	operations := make([]LTRBackupOperation, 0)
+	for operation, err := range ListARMResources[armLTRBackupOperation](dp.ctx, client, serverID+"/ltrBackupOperations", flexibleServersAPIVersion) {
+		if err != nil {
+			return nil, err
+		}
+
+		o := LTRBackupOperation{}
+		if operation.Name != nil {
+			o.Name = *operation.Name
+		}
+		if properties := operation.Properties; properties != nil {
+			if properties.BackupName != nil {
+				o.BackupName = *properties.BackupName
+			}
+			if properties.Status != nil {
+				o.Status = *properties.Status
+			}
+			if properties.ErrorMessage != nil {
+				o.ErrorMessage = *properties.ErrorMessage
+			}
+			o.StartTime = properties.StartTime
+			o.EndTime = properties.EndTime
+		}
+		operations = append(operations, o)
+	}
+
+	sort.SliceStable(operations, func(i, j int) bool {
+		return newerThan(operations[i].StartTime, operations[j].StartTime)
+	})
+	return operations, nil
+}
+
+// newerThan orders times newest first, with unknown times last.
+func newerThan(a *time.Time, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a != nil && b == nil
+	}
+	return a.After(*b)
+}
+
+// AvailableBackup is a completed backup of a server that it can be restored from.
+type AvailableBackup struct {
+	Name string `json:"name"`
+	// Type is Full for automated backups or Customer On-Demand for backups taken on request.
+	Type          string     `json:"type,omitempty"`
+	CompletedTime *time.Time `json:"completed_time,omitempty"`
+}
+
+type armBackup struct {
+	Name       *string `json:"name"`
+	Properties *struct {
+		BackupType    *string    `json:"backupType"`
+		CompletedTime *time.Time `json:"completedTime"`
+	} `json:"properties"`
+}
+
+// GetBackups lists the available backups of a server, newest first.
+func (dp *AzureDataProcessor) GetBackups(serverID string) ([]AvailableBackup, error) {
+	client, err := dp.getARMClient()
+	if err != nil {
+		return nil, err
+	}
+
+	backups := make([]AvailableBackup, 0)
+	for backup, err := range ListARMResources[armBackup](dp.ctx, client, serverID+"/backups", flexibleServersAPIVersion) {
+		if err != nil {
+			return nil, err
+		}
+
+		available := AvailableBackup{}
+		if backup.Name != nil {
+			available.Name = *backup.Name
+		}
+		if backup.Properties != nil {
+			if backup.Properties.BackupType != nil {
+				available.Type = *backup.Properties.BackupType
+			}
+			available.CompletedTime = backup.Properties.CompletedTime
+		}
+		backups = append(backups, available)
+	}
+
+	sort.SliceStable(backups, func(i, j int) bool {
+		return newerThan(backups[i].CompletedTime, backups[j].CompletedTime)
+	})
+	return backups, nil
+}
+
+// SetBackups records the available backups and the newest of them.
+func (b *BackupConfig) SetBackups(backups []AvailableBackup) {
+	b.Backups = backups
+	if len(backups) > 0 {
+		b.LatestBackup = backups[0].CompletedTime
+	}
+}
+
+// NewBackupConfig summarises the server's backup configuration, treating a missing configuration as unknown.
+func NewBackupConfig(server *armpostgresqlflexibleservers.Server) *BackupConfig {
+	backup := &BackupConfig{
+		GeoRedundantBackup: backupUnknown,
+	}
+	if server.Properties == nil || server.Properties.Backup == nil {
+		return backup
+	}
+
+	backup.RetentionDays = server.Properties.Backup.BackupRetentionDays
+	backup.EarliestRestoreDate = server.Properties.Backup.EarliestRestoreDate
+	if server.Properties.Backup.GeoRedundantBackup != nil {
+		backup.GeoRedundantBackup = string(*server.Properties.Backup.GeoRedundantBackup)
+	}
+	return backup
+}
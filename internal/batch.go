@@ -0,0 +1,82 @@
+package internal
+
+import (
+	"time"
+
+	"github.com/compliance-framework/agent/runner/proto"
+)
+
+// defaultEvidenceBatchSize keeps each write well within the API's payload limit.
+const defaultEvidenceBatchSize = 50
+
+// queueEvidence adds a server's evidence to the run's pending evidence, writing a batch each time enough evidence
+// has built up across servers. The rest is written by flushEvidence once every server has been assessed.
+func (dp *AzureDataProcessor) queueEvidence(run *serverRun, evidences []*proto.Evidence) {
+	run.pendingMu.Lock()
+	run.pending = append(run.pending, evidences...)
+	batches := make([][]*proto.Evidence, 0)
+	for len(run.pending) >= run.batchSize {
+		batches = append(batches, run.pending[:run.batchSize:run.batchSize])
+		run.pending = run.pending[run.batchSize:]
+	}
+	run.pendingMu.Unlock()
+
+	for _, batch := range batches {
+		dp.writeBatch(run, batch)
+	}
+}
+
+// flushEvidence writes any evidence still pending.
+func (dp *AzureDataProcessor) flushEvidence(run *serverRun) {
+	run.pendingMu.Lock()
+	batch := run.pending
+	run.pending = nil
+	run.pendingMu.Unlock()
+
+	if len(batch) > 0 {
+		dp.writeBatch(run, batch)
+	}
+}
+
+// newEvidenceBatch numbers the evidence as the run's next batch.
+func (dp *AzureDataProcessor) newEvidenceBatch(evidences []*proto.Evidence) *EvidenceBatch {
+	return &EvidenceBatch{
+		Sequence: dp.evidenceBatches.Add(1),
+		Created:  time.Now(),
+		Evidence: evidences,
+	}
+}
+
+// writeBatch writes a batch to every sink, retrying a failed sink once. The retry writes the same numbered batch, so
+// a sink that partly wrote it the first time replaces what it wrote. A batch that still fails is recorded as an
+// error against the sink and dropped for that sink, so later evidence is still written.
+func (dp *AzureDataProcessor) writeBatch(run *serverRun, evidences []*proto.Evidence) {
+	ctx, cancel := dp.writeContext()
+	defer cancel()
+
+	start := time.Now()
+	defer func() {
+		dp.logger.Debug("Evidence batch written", "count", len(evidences), "duration", observe(&run.metrics.submission, start))
+	}()
+
+	batch := dp.newEvidenceBatch(evidences)
+	written := true
+	for _, sink := range dp.sinks {
+		err := sink.WriteEvidence(ctx, batch)
+		if err != nil {
+			dp.logger.Warn("Error creating evidence, retrying", "sink", sink.Name(), "batch", batch.Sequence, "count", len(evidences), "error", err)
+			err = sink.WriteEvidence(ctx, batch)
+		}
+		if err != nil {
+			dp.logger.Error("Error creating evidence", "sink", sink.Name(), "batch", batch.Sequence, "count", len(evidences), "error", err)
+			run.errs.Add(sink.Name(), "create evidence", "", err)
+			run.failed.Store(true)
+			written = false
+			continue
+		}
+		run.metrics.recordSubmitted(sink.Name(), len(evidences))
+	}
+	if written {
+		run.metrics.submitted.Add(int64(len(evidences)))
+	}
+}
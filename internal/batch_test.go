@@ -0,0 +1,69 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/compliance-framework/agent/runner/proto"
+)
+
+// flakySink fails the first failures writes and records the sequence number of every batch it was given.
+type flakySink struct {
+	name      string
+	failures  int
+	sequences []int64
+}
+
+func (s *flakySink) Name() string {
+	return s.name
+}
+
+func (s *flakySink) WriteEvidence(_ context.Context, batch *EvidenceBatch) error {
+	s.sequences = append(s.sequences, batch.Sequence)
+	if len(s.sequences) <= s.failures {
+		return errors.New("unavailable")
+	}
+	return nil
+}
+
+func (s *flakySink) WriteServerData(context.Context, *ServerData) error {
+	return nil
+}
+
+func TestWriteBatch(t *testing.T) {
+	dp, _ := newTestProcessor(t, "sub-a", fakeLister{})
+	retried := &flakySink{name: "retried", failures: 1}
+	failing := &flakySink{name: "failing", failures: 2}
+	dp.sinks = []EvidenceSink{retried, failing}
+	run := &serverRun{errs: NewErrorAggregator(), metrics: newRunMetrics()}
+
+	dp.writeBatch(run, []*proto.Evidence{{}, {}})
+
+	// The retry writes the same batch, so sinks that name writes after it replace rather than duplicate them.
+	if !slices.Equal(retried.sequences, []int64{1, 1}) {
+		t.Errorf("retried sink given batches %v, want [1 1]", retried.sequences)
+	}
+
+	if got := run.metrics.submittedBySinks(); got["retried"] != 2 || got["failing"] != 0 {
+		t.Errorf("submitted by sink = %v, want 2 for the retried sink only", got)
+	}
+	if got := run.metrics.submitted.Load(); got != 0 {
+		t.Errorf("submitted to every sink = %d, want 0", got)
+	}
+
+	report := run.errs.Report()
+	if len(report) != 1 || report[0].Scope != "failing" || report[0].Operation != "create evidence" {
+		t.Errorf("error report = %+v, want one create evidence error for the failing sink", report)
+	}
+	if !run.failed.Load() {
+		t.Errorf("run not marked as failed")
+	}
+
+	// The next batch is numbered on from the last.
+	dp.writeBatch(run, []*proto.Evidence{{}})
+	if got := retried.sequences[len(retried.sequences)-1]; got != 2 {
+		t.Errorf("next batch numbered %d, want 2", got)
+	}
+}
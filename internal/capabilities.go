@@ -0,0 +1,119 @@
+package internal
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/postgresql/armpostgresqlflexibleservers"
+)
+
+// LocationCapabilities is what Azure offers flexible servers in a server's region, so policies can tell a feature
+// that isn't configured apart from one that isn't available.
+type LocationCapabilities struct {
+	Location string `json:"location"`
+	// Zones are the availability zones servers can be placed in, empty for a region without zones.
+	Zones []string `json:"zones"`
+	// SupportedHAModes are the high availability modes offered in any zone, e.g. SameZone and ZoneRedundant.
+	SupportedHAModes                     []string `json:"supported_ha_modes"`
+	ZoneRedundantHASupported             bool     `json:"zone_redundant_ha_supported"`
+	GeoBackupSupported                   bool     `json:"geo_backup_supported"`
+	ZoneRedundantHAAndGeoBackupSupported bool     `json:"zone_redundant_ha_and_geo_backup_supported"`
+	// ServerEditions are the SKU tiers offered, e.g. Burstable, GeneralPurpose and MemoryOptimized.
+	ServerEditions []string `json:"server_editions"`
+	// StorageEditions are the storage types offered by any server edition, e.g. ManagedDisk.
+	StorageEditions []string `json:"storage_editions"`
+}
+
+type capabilitiesResult struct {
+	capabilities *LocationCapabilities
+	err          error
+}
+
+// HighAvailabilityAvailable reports whether any high availability mode is offered in the region.
+func (c *LocationCapabilities) HighAvailabilityAvailable() bool {
+	return len(c.SupportedHAModes) > 0 || c.ZoneRedundantHASupported
+}
+
+// GetLocationCapabilities fetches the flexible server capabilities of a region. Servers share a handful of regions,
+// so results, including failures, are cached per subscription and region for the rest of the run.
+func (dp *AzureDataProcessor) GetLocationCapabilities(subscriptionID string, location string) (*LocationCapabilities, error) {
+	dp.capabilitiesMu.Lock()
+	defer dp.capabilitiesMu.Unlock()
+
+	// Offers can differ between subscriptions, e.g. for restricted regions.
+	key := strings.ToLower(subscriptionID + "/" + location)
+	if result, ok := dp.capabilities[key]; ok {
+		return result.capabilities, result.err
+	}
+
+	capabilities, err := dp.getLocationCapabilities(subscriptionID, location)
+	dp.capabilities[key] = capabilitiesResult{capabilities: capabilities, err: err}
+	return capabilities, err
+}
+
+func (dp *AzureDataProcessor) getLocationCapabilities(subscriptionID string, location string) (*LocationCapabilities, error) {
+	client, err := armpostgresqlflexibleservers.NewLocationBasedCapabilitiesClient(subscriptionID, dp.credential, dp.clientOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	capabilities := &LocationCapabilities{
+		Location:         location,
+		Zones:            make([]string, 0),
+		SupportedHAModes: make([]string, 0),
+		ServerEditions:   make([]string, 0),
+		StorageEditions:  make([]string, 0),
+	}
+	pager := client.NewExecutePager(location, nil)
+	for pager.More() {
+		page, err := pager.NextPage(dp.ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		// The region is reported once per zone, with the zone "none" describing servers placed without one.
+		for _, capability := range page.Value {
+			if capability == nil {
+				continue
+			}
+			if capability.Zone != nil && *capability.Zone != "" && !strings.EqualFold(*capability.Zone, "none") {
+				capabilities.Zones = appendUnique(capabilities.Zones, *capability.Zone)
+			}
+			for _, mode := range capability.SupportedHAMode {
+				if mode != nil {
+					capabilities.SupportedHAModes = appendUnique(capabilities.SupportedHAModes, *mode)
+				}
+			}
+			if capability.ZoneRedundantHaSupported != nil && *capability.ZoneRedundantHaSupported {
+				capabilities.ZoneRedundantHASupported = true
+			}
+			if capability.GeoBackupSupported != nil && *capability.GeoBackupSupported {
+				capabilities.GeoBackupSupported = true
+			}
+			if capability.ZoneRedundantHaAndGeoBackupSupported != nil && *capability.ZoneRedundantHaAndGeoBackupSupported {
+				capabilities.ZoneRedundantHAAndGeoBackupSupported = true
+			}
+			for _, edition := range capability.SupportedFlexibleServerEditions {
+				if edition == nil {
+					continue
+				}
+				if edition.Name != nil {
+					capabilities.ServerEditions = appendUnique(capabilities.ServerEditions, *edition.Name)
+				}
+				for _, storage := range edition.SupportedStorageEditions {
+					if storage != nil && storage.Name != nil {
+						capabilities.StorageEditions = appendUnique(capabilities.StorageEditions, *storage.Name)
+					}
+				}
+			}
+		}
+	}
+	return capabilities, nil
+}
+
+func appendUnique(values []string, value string) []string {
+	if slices.Contains(values, value) {
+		return values
+	}
+	return append(values, value)
+}
@@ -0,0 +1,227 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/postgresql/armpostgresqlflexibleservers"
+	"github.com/compliance-framework/agent/runner/proto"
+)
+
+// runBuiltinChecks produces the evidence for checks the plugin performs itself, alongside any policy evaluation.
+func (dp *AzureDataProcessor) runBuiltinChecks(ec *EvidenceContext, data *ServerData) []*proto.Evidence {
+	evidences := make([]*proto.Evidence, 0)
+
+	if evidence := dp.checkSSLPosture(ec, data); evidence != nil {
+		evidences = append(evidences, evidence)
+	}
+
+	if ConfigBool(dp.config, "check_storage_tier") {
+		if evidence := dp.checkStorageTier(ec, data); evidence != nil {
+			evidences = append(evidences, evidence)
+		}
+	}
+
+	if dp.maintenanceSchedule != nil {
+		if evidence := dp.checkMaintenanceWindow(ec, data); evidence != nil {
+			evidences = append(evidences, evidence)
+		}
+	}
+
+	if evidence := dp.checkAdvisorRecommendations(ec, data); evidence != nil {
+		evidences = append(evidences, evidence)
+	}
+
+	if ConfigBool(dp.config, "emit_collection_warnings") {
+		if evidence := dp.checkCollectionWarnings(ec, data); evidence != nil {
+			evidences = append(evidences, evidence)
+		}
+	}
+
+	return evidences
+}
+
+// checkSSLPosture flags servers whose SSL enforcement could not be determined, so that policies evaluated on
+// incomplete data don't give false assurance. The evidence API has no inconclusive state, so the evidence is
+// reported as not satisfied with an "inconclusive" reason.
+func (dp *AzureDataProcessor) checkSSLPosture(ec *EvidenceContext, data *ServerData) *proto.Evidence {
+	if data.SSL == nil || data.SSL.Determinable {
+		return nil
+	}
+
+	evidence, err := ec.NewEvidence(
+		"builtin_ssl_posture",
+		fmt.Sprintf("SSL enforcement on %s could not be determined.", *data.Name),
+		fmt.Sprintf("The plugin could not determine whether %s enforces SSL connections, so SSL related policy results for this server are inconclusive: %s.", *data.Name, data.SSL.Reason),
+		&proto.EvidenceStatus{
+			Reason:  "inconclusive",
+			Remarks: data.SSL.Reason,
+			State:   proto.EvidenceStatusState_EVIDENCE_STATUS_STATE_NOT_SATISFIED,
+		},
+	)
+	if err != nil {
+		dp.logger.Error("Error creating SSL posture evidence", "server", *data.ID, "error", err)
+		return nil
+	}
+	return evidence
+}
+
+// checkStorageTier reports whether the server's storage tier and type are suitable for its SKU tier, according to
+// the storage_tier_rules table.
+func (dp *AzureDataProcessor) checkStorageTier(ec *EvidenceContext, data *ServerData) *proto.Evidence {
+	if data.storageMismatch == nil {
+		return nil
+	}
+
+	title := fmt.Sprintf("Storage tier on %s matches its SKU tier.", *data.Name)
+	description := fmt.Sprintf("The storage tier and type of %s are suitable for its SKU tier.", *data.Name)
+	status := &proto.EvidenceStatus{
+		Reason:  "pass",
+		Remarks: title,
+		State:   proto.EvidenceStatusState_EVIDENCE_STATUS_STATE_SATISFIED,
+	}
+	if *data.storageMismatch != "" {
+		title = fmt.Sprintf("Storage tier on %s does not match its SKU tier.", *data.Name)
+		description = fmt.Sprintf("%s uses %s storage, which is listed as a mismatch for its SKU tier.", *data.Name, *data.storageMismatch)
+		status = &proto.EvidenceStatus{
+			Reason:  "fail",
+			Remarks: description,
+			State:   proto.EvidenceStatusState_EVIDENCE_STATUS_STATE_NOT_SATISFIED,
+		}
+	}
+
+	evidence, err := ec.NewEvidence("builtin_storage_tier", title, description, status)
+	if err != nil {
+		dp.logger.Error("Error creating storage tier evidence", "server", *data.ID, "error", err)
+		return nil
+	}
+	return evidence
+}
+
+// checkMaintenanceWindow reports whether the server's maintenance window falls within the approved schedule.
+func (dp *AzureDataProcessor) checkMaintenanceWindow(ec *EvidenceContext, data *ServerData) *proto.Evidence {
+	var window *armpostgresqlflexibleservers.MaintenanceWindow
+	if data.Properties != nil {
+		window = data.Properties.MaintenanceWindow
+	}
+
+	title := fmt.Sprintf("Maintenance window on %s is within the approved schedule.", *data.Name)
+	description := fmt.Sprintf("The maintenance window of %s falls within the approved maintenance days and hours.", *data.Name)
+	status := &proto.EvidenceStatus{
+		Reason:  "pass",
+		Remarks: title,
+		State:   proto.EvidenceStatusState_EVIDENCE_STATUS_STATE_SATISFIED,
+	}
+	if compliant, reason := dp.maintenanceSchedule.Evaluate(window, ConfigBool(dp.config, "allow_system_maintenance_window")); !compliant {
+		title = fmt.Sprintf("Maintenance window on %s is outside the approved schedule.", *data.Name)
+		description = fmt.Sprintf("The maintenance window of %s does not comply with the approved schedule: %s.", *data.Name, reason)
+		status = &proto.EvidenceStatus{
+			Reason:  "fail",
+			Remarks: reason,
+			State:   proto.EvidenceStatusState_EVIDENCE_STATUS_STATE_NOT_SATISFIED,
+		}
+	}
+
+	evidence, err := ec.NewEvidence("builtin_maintenance_window", title, description, status)
+	if err != nil {
+		dp.logger.Error("Error creating maintenance window evidence", "server", *data.ID, "error", err)
+		return nil
+	}
+	return evidence
+}
+
+// checkCollectionWarnings records the optional collections that failed for a server, so gaps in the data policies
+// were evaluated against are auditable. Like other incomplete data, it is reported as not satisfied.
+func (dp *AzureDataProcessor) checkCollectionWarnings(ec *EvidenceContext, data *ServerData) *proto.Evidence {
+	if len(data.warnings) == 0 {
+		return nil
+	}
+
+	failures := make([]string, 0)
+	for _, warning := range data.warnings {
+		failures = append(failures, fmt.Sprintf("%s: %s", warning.Collection, warning.Error))
+	}
+
+	evidence, err := ec.NewEvidence(
+		"builtin_collection_warning",
+		fmt.Sprintf("Some data could not be collected for %s.", *data.Name),
+		fmt.Sprintf("%d optional collection(s) failed for %s, so policies were evaluated on partial data. %s.", len(failures), *data.Name, strings.Join(failures, "; ")),
+		&proto.EvidenceStatus{
+			Reason:  "warning",
+			Remarks: strings.Join(failures, "; "),
+			State:   proto.EvidenceStatusState_EVIDENCE_STATUS_STATE_NOT_SATISFIED,
+		},
+	)
+	if err != nil {
+		dp.logger.Error("Error creating collection warning evidence", "server", *data.ID, "error", err)
+		return nil
+	}
+	return evidence
+}
+
+// checkAdvisorRecommendations reports Azure Advisor's own guidance for the server alongside policy results. It is
+// only emitted when the recommendations could be collected, and fails while any recommendation is active.
+func (dp *AzureDataProcessor) checkAdvisorRecommendations(ec *EvidenceContext, data *ServerData) *proto.Evidence {
+	if data.AdvisorRecommendations == nil {
+		return nil
+	}
+
+	title := fmt.Sprintf("Azure Advisor has no recommendations for %s.", *data.Name)
+	description := fmt.Sprintf("Azure Advisor has no active recommendations for %s.", *data.Name)
+	status := &proto.EvidenceStatus{
+		Reason:  "pass",
+		Remarks: title,
+		State:   proto.EvidenceStatusState_EVIDENCE_STATUS_STATE_SATISFIED,
+	}
+	if len(data.AdvisorRecommendations) > 0 {
+		problems := make([]string, 0)
+		for _, recommendation := range data.AdvisorRecommendations {
+			problems = append(problems, fmt.Sprintf("%s (%s, %s impact)", recommendation.Problem, recommendation.Category, recommendation.Impact))
+		}
+		title = fmt.Sprintf("Azure Advisor has %d recommendation(s) for %s.", len(problems), *data.Name)
+		description = fmt.Sprintf("Azure Advisor has active recommendations for %s, %d of them high impact: %s.", *data.Name, len(HighImpactRecommendations(data.AdvisorRecommendations)), strings.Join(problems, "; "))
+		status = &proto.EvidenceStatus{
+			Reason:  "fail",
+			Remarks: strings.Join(problems, "; "),
+			State:   proto.EvidenceStatusState_EVIDENCE_STATUS_STATE_NOT_SATISFIED,
+		}
+	}
+
+	evidence, err := ec.NewEvidence("builtin_advisor_recommendations", title, description, status)
+	if err != nil {
+		dp.logger.Error("Error creating advisor recommendations evidence", "server", *data.ID, "error", err)
+		return nil
+	}
+	return evidence
+}
+
+// serverState returns the server's provisioning state and whether it is ready to be assessed. A server that doesn't
+// report a state, such as a single server, is assumed to be ready.
+func serverState(server *armpostgresqlflexibleservers.Server) (string, bool) {
+	if server.Properties == nil || server.Properties.State == nil {
+		return "", true
+	}
+	state := string(*server.Properties.State)
+	return state, strings.EqualFold(state, string(armpostgresqlflexibleservers.ServerStateReady))
+}
+
+// checkServerState records a server that was not assessed because it is not ready. As with an undeterminable SSL
+// posture, the evidence is reported as not satisfied with an "inconclusive" reason.
+func (dp *AzureDataProcessor) checkServerState(ec *EvidenceContext, data *ServerData, state string) []*proto.Evidence {
+	remarks := fmt.Sprintf("The server is in the %s state.", state)
+	evidence, err := ec.NewEvidence(
+		"builtin_server_state",
+		fmt.Sprintf("%s was not assessed as it is not ready.", *data.Name),
+		fmt.Sprintf("%s is in the %s state, so its configuration was not collected and no policies were evaluated. It will be assessed once it is Ready.", *data.Name, state),
+		&proto.EvidenceStatus{
+			Reason:  "inconclusive",
+			Remarks: remarks,
+			State:   proto.EvidenceStatusState_EVIDENCE_STATUS_STATE_NOT_SATISFIED,
+		},
+	)
+	if err != nil {
+		dp.logger.Error("Error creating server state evidence", "server", *data.ID, "error", err)
+		return nil
+	}
+	return []*proto.Evidence{evidence}
+}
@@ -0,0 +1,125 @@
+package internal
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+	azlog "github.com/Azure/azure-sdk-for-go/sdk/azcore/log"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/hashicorp/go-hclog"
+)
+
+// defaultMaxRetries matches the Azure SDK's own default.
+const defaultMaxRetries = 3
+
+const (
+	CloudPublic = "public"
+	CloudUSGov  = "usgov"
+	CloudChina  = "china"
+)
+
+// ParseCloud returns the Azure cloud selected by the cloud config, defaulting to the public cloud.
+func ParseCloud(config map[string]string) (cloud.Configuration, error) {
+	switch name := strings.ToLower(ConfigString(config, "cloud", CloudPublic)); name {
+	case CloudPublic:
+		return cloud.AzurePublic, nil
+	case CloudUSGov:
+		return cloud.AzureGovernment, nil
+	case CloudChina:
+		return cloud.AzureChina, nil
+	default:
+		return cloud.Configuration{}, fmt.Errorf("unsupported cloud %q, expected one of %s, %s or %s", config["cloud"], CloudPublic, CloudUSGov, CloudChina)
+	}
+}
+
+// NewTransport builds the HTTP client for Azure requests from proxy_url and insecure_skip_verify. It returns nil when
+// neither is set, leaving the SDK's default client, which honours the proxy environment variables.
+func NewTransport(config map[string]string) (policy.Transporter, error) {
+	proxyURL := strings.TrimSpace(config["proxy_url"])
+	insecure := ConfigBool(config, "insecure_skip_verify")
+	if proxyURL == "" && !insecure {
+		return nil, nil
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if proxyURL != "" {
+		proxy, err := url.Parse(proxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("proxy_url is not a valid URL: %w", err)
+		}
+		if (proxy.Scheme != "http" && proxy.Scheme != "https") || proxy.Host == "" {
+			return nil, fmt.Errorf("proxy_url %q must be an http or https URL with a host", proxyURL)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+	if insecure {
+		// Only intended for test environments, e.g. behind an intercepting proxy.
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	return &http.Client{Transport: transport}, nil
+}
+
+// NewClientOptions builds the options shared by every Azure client in a run. The SDK retry policy backs off
+// exponentially with jitter and honours Retry-After on throttled responses, so only the attempts are configured.
+func NewClientOptions(config map[string]string) (*arm.ClientOptions, error) {
+	azureCloud, err := ParseCloud(config)
+	if err != nil {
+		return nil, err
+	}
+
+	transport, err := NewTransport(config)
+	if err != nil {
+		return nil, err
+	}
+
+	maxRetries, err := ConfigInt(config, "max_retries", defaultMaxRetries)
+	if err != nil {
+		return nil, err
+	}
+	if maxRetries < 0 {
+		return nil, fmt.Errorf("max_retries must not be negative")
+	}
+
+	retry := policy.RetryOptions{
+		MaxRetries: int32(maxRetries),
+	}
+	// A MaxRetries of zero means the SDK default, so disabling retries needs a negative value.
+	if maxRetries == 0 {
+		retry.MaxRetries = -1
+	}
+
+	options := &arm.ClientOptions{
+		ClientOptions: policy.ClientOptions{
+			Cloud: azureCloud,
+			Retry: retry,
+		},
+	}
+	if transport != nil {
+		options.Transport = transport
+	}
+	return options, nil
+}
+
+var retryLogging sync.Once
+
+// logRetries forwards the Azure SDK's retry events to the plugin logger at debug level.
+// The SDK only supports a single process wide listener, so it is registered once.
+func logRetries(logger hclog.Logger) {
+	retryLogging.Do(func() {
+		azlog.SetEvents(azlog.EventRetryPolicy)
+		azlog.SetListener(func(event azlog.Event, message string) {
+			// The retry policy ends each failed attempt with "End Try #<attempt>, Delay=<delay>".
+			attempt, delay, ok := strings.Cut(strings.TrimPrefix(message, "End Try #"), ", Delay=")
+			if !ok || !strings.HasPrefix(message, "End Try #") {
+				return
+			}
+			logger.Debug("Retrying Azure request", "attempt", attempt, "delay", delay)
+		})
+	})
+}
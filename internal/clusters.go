@@ -0,0 +1,178 @@
+package internal
+
+import (
+	"context"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/postgresql/armpostgresqlflexibleservers"
+)
+
+// clustersAPIVersion is the ARM API version of the Microsoft.DBforPostgreSQL/serverGroupsv2 resource. Clusters are
+// read through the generic ARM client, as the plugin does not depend on the armcosmosforpostgresql SDK.
+const clustersAPIVersion = "2022-11-08"
+
+// ClusterProperties are the Azure Cosmos DB for PostgreSQL cluster settings in the policy input.
+type ClusterProperties struct {
+	PostgreSQLVersion string `json:"postgresql_version,omitempty"`
+	CitusVersion      string `json:"citus_version,omitempty"`
+	State             string `json:"state,omitempty"`
+	// HighAvailabilityEnabled provisions a standby for the coordinator and every worker node.
+	HighAvailabilityEnabled bool              `json:"high_availability_enabled"`
+	PreferredPrimaryZone    string            `json:"preferred_primary_zone,omitempty"`
+	ShardsOnCoordinator     bool              `json:"shards_on_coordinator"`
+	Coordinator             ClusterNodeConfig `json:"coordinator"`
+	// Workers is the configuration shared by every worker node. A single node cluster has no workers.
+	Workers ClusterNodeConfig `json:"workers"`
+}
+
+// ClusterNodeConfig is the compute and storage configuration of the coordinator, or of the worker nodes.
+type ClusterNodeConfig struct {
+	// Count is the number of nodes, always 1 for the coordinator.
+	Count int32 `json:"count"`
+	// ServerEdition is BurstableMemoryOptimized, BurstableGeneralPurpose, GeneralPurpose or MemoryOptimized.
+	ServerEdition  string `json:"server_edition,omitempty"`
+	VCores         *int32 `json:"vcores,omitempty"`
+	StorageQuotaMB *int32 `json:"storage_quota_mb,omitempty"`
+	PublicIPAccess bool   `json:"public_ip_access"`
+}
+
+// Cluster is the subset of the cluster resource that the flexible server SDK model doesn't carry.
+type Cluster struct {
+	Properties *ClusterResourceProperties `json:"properties,omitempty"`
+}
+
+type ClusterResourceProperties struct {
+	PostgresqlVersion               *string `json:"postgresqlVersion,omitempty"`
+	CitusVersion                    *string `json:"citusVersion,omitempty"`
+	State                           *string `json:"state,omitempty"`
+	EnableHa                        *bool   `json:"enableHa,omitempty"`
+	PreferredPrimaryZone            *string `json:"preferredPrimaryZone,omitempty"`
+	EnableShardsOnCoordinator       *bool   `json:"enableShardsOnCoordinator,omitempty"`
+	CoordinatorServerEdition        *string `json:"coordinatorServerEdition,omitempty"`
+	CoordinatorVCores               *int32  `json:"coordinatorVCores,omitempty"`
+	CoordinatorStorageQuotaInMb     *int32  `json:"coordinatorStorageQuotaInMb,omitempty"`
+	CoordinatorEnablePublicIPAccess *bool   `json:"coordinatorEnablePublicIpAccess,omitempty"`
+	NodeCount                       *int32  `json:"nodeCount,omitempty"`
+	NodeServerEdition               *string `json:"nodeServerEdition,omitempty"`
+	NodeVCores                      *int32  `json:"nodeVCores,omitempty"`
+	NodeStorageQuotaInMb            *int32  `json:"nodeStorageQuotaInMb,omitempty"`
+	NodeEnablePublicIPAccess        *bool   `json:"nodeEnablePublicIpAccess,omitempty"`
+}
+
+// GetCluster fetches a cluster through ARM.
+func (dp *AzureDataProcessor) GetCluster(clusterID string) (*Cluster, error) {
+	client, err := dp.getARMClient()
+	if err != nil {
+		return nil, err
+	}
+
+	cluster := &Cluster{}
+	if err := client.Get(dp.ctx, clusterID, clustersAPIVersion, cluster); err != nil {
+		return nil, err
+	}
+	return cluster, nil
+}
+
+// NewClusterProperties extracts the cluster settings for the policy input.
+func NewClusterProperties(cluster *Cluster) *ClusterProperties {
+	properties := &ClusterProperties{
+		Coordinator: ClusterNodeConfig{Count: 1},
+	}
+	source := cluster.Properties
+	if source == nil {
+		return properties
+	}
+
+	if source.PostgresqlVersion != nil {
+		properties.PostgreSQLVersion = *source.PostgresqlVersion
+	}
+	if source.CitusVersion != nil {
+		properties.CitusVersion = *source.CitusVersion
+	}
+	if source.State != nil {
+		properties.State = *source.State
+	}
+	if source.EnableHa != nil {
+		properties.HighAvailabilityEnabled = *source.EnableHa
+	}
+	if source.PreferredPrimaryZone != nil {
+		properties.PreferredPrimaryZone = *source.PreferredPrimaryZone
+	}
+	if source.EnableShardsOnCoordinator != nil {
+		properties.ShardsOnCoordinator = *source.EnableShardsOnCoordinator
+	}
+
+	if source.CoordinatorServerEdition != nil {
+		properties.Coordinator.ServerEdition = *source.CoordinatorServerEdition
+	}
+	properties.Coordinator.VCores = source.CoordinatorVCores
+	properties.Coordinator.StorageQuotaMB = source.CoordinatorStorageQuotaInMb
+	if source.CoordinatorEnablePublicIPAccess != nil {
+		properties.Coordinator.PublicIPAccess = *source.CoordinatorEnablePublicIPAccess
+	}
+
+	if source.NodeCount != nil {
+		properties.Workers.Count = *source.NodeCount
+	}
+	if source.NodeServerEdition != nil {
+		properties.Workers.ServerEdition = *source.NodeServerEdition
+	}
+	properties.Workers.VCores = source.NodeVCores
+	properties.Workers.StorageQuotaMB = source.NodeStorageQuotaInMb
+	if source.NodeEnablePublicIPAccess != nil {
+		properties.Workers.PublicIPAccess = *source.NodeEnablePublicIPAccess
+	}
+	return properties
+}
+
+// NewClusterNetworkConfig summarises a cluster's network configuration. A cluster is publicly accessible when its
+// coordinator or any worker node has public IP access, and its private endpoints aren't collected.
+func NewClusterNetworkConfig(properties *ClusterProperties) *NetworkConfig {
+	network := &NetworkConfig{
+		PublicNetworkAccess: networkUnknown,
+		PrivateEndpoints:    make([]PrivateEndpoint, 0),
+	}
+	if properties != nil {
+		network.PublicNetworkAccess = "Disabled"
+		if properties.Coordinator.PublicIPAccess || (properties.Workers.Count > 0 && properties.Workers.PublicIPAccess) {
+			network.PublicNetworkAccess = "Enabled"
+		}
+	}
+	return network
+}
+
+// GetClusterFirewallRules lists the firewall rules of a cluster, which apply to its coordinator.
+func (dp *AzureDataProcessor) GetClusterFirewallRules(clusterID string) ([]FirewallRule, error) {
+	return dp.getARMFirewallRules(clusterID, clustersAPIVersion)
+}
+
+// listClusters yields the Azure Cosmos DB for PostgreSQL clusters of a subscription, or of one of its resource
+// groups when set. As with single servers, the cluster resource is decoded into the flexible server SDK model,
+// which keeps its ID, name, location, tags, state and administrator login. It returns false once the consumer
+// stops iterating.
+func (l *AzureServerLister) listClusters(ctx context.Context, client *ARMClient, subscriptionID string, resourceGroup string, yield func(*armpostgresqlflexibleservers.Server, error) bool) bool {
+	path := "/subscriptions/" + subscriptionID
+	if resourceGroup != "" {
+		path += "/resourceGroups/" + resourceGroup
+	}
+	path += "/providers/Microsoft.DBforPostgreSQL/serverGroupsv2"
+
+	for cluster, err := range ListARMResources[armpostgresqlflexibleservers.Server](ctx, client, path, clustersAPIVersion) {
+		if err != nil {
+			l.logger.Error("unable to list Azure Cosmos DB for PostgreSQL clusters", "subscription", subscriptionID, "resource_group", resourceGroup, "error", err)
+			return yield(nil, &CollectionError{
+				Operation:      "list clusters",
+				SubscriptionID: subscriptionID,
+				ResourceGroup:  resourceGroup,
+				Err:            err,
+				Fatal:          IsFatalError(err),
+			})
+		}
+		if cluster.ID == nil || ServerTypeOf(*cluster.ID) != ServerTypeCluster {
+			continue
+		}
+		if !yield(&cluster, nil) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,683 @@
+package internal
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/postgresql/armpostgresqlflexibleservers"
+)
+
+// CollectionWarning records an optional collection that failed for a server. The server is still evaluated
+// with the data that could be collected.
+type CollectionWarning struct {
+	Collection string
+	Error      error
+}
+
+// newServerData starts the policy input for a server with what is known from listing it, which is all that is
+// reported for servers that are not ready.
+func (dp *AzureDataProcessor) newServerData(server *armpostgresqlflexibleservers.Server) *ServerData {
+	data := &ServerData{
+		Server: server,
+		ServerExtensions: ServerExtensions{
+			ServerType:     ServerTypeOf(*server.ID),
+			NormalisedTags: NormaliseTags(server.Tags),
+		},
+	}
+	if environment, ok := data.NormalisedTags[strings.ToLower(ConfigString(dp.config, "environment_tag", defaultEnvironmentTag))]; ok {
+		data.Environment = environment
+	}
+	return data
+}
+
+// collectServerData builds the policy input for a server, enriching it with data the SDK object doesn't carry.
+func (dp *AzureDataProcessor) collectServerData(server *armpostgresqlflexibleservers.Server) *ServerData {
+	data := dp.newServerData(server)
+
+	// Other kinds of server have none of the flexible server child resources, so they get collections of their own.
+	switch data.ServerType {
+	case ServerTypeSingle:
+		return dp.collectSingleServerData(data)
+	case ServerTypeCluster:
+		return dp.collectClusterData(data)
+	case ServerTypeArc:
+		return dp.collectArcData(data)
+	}
+
+	extended, err := dp.GetExtendedServer(*server.ID)
+	if err != nil {
+		dp.collectionWarning(data, "extended server properties", err)
+		extended = nil
+	}
+	data.Facts = DeriveServerFacts(server, extended)
+	data.HighAvailability = NewHighAvailability(server, extended)
+	data.Storage = NewStorageConfig(server, extended)
+	dp.collectLocationCapabilities(data)
+
+	var window *armpostgresqlflexibleservers.MaintenanceWindow
+	if server.Properties != nil {
+		window = server.Properties.MaintenanceWindow
+	}
+	data.Maintenance = NewServerMaintenanceWindow(window)
+	data.Facts.CustomMaintenanceWindow = BoolAddressed(!data.Maintenance.SystemManaged)
+	data.Backup = NewBackupConfig(server)
+	backups, err := dp.GetBackups(*server.ID)
+	if err != nil {
+		dp.collectionWarning(data, "backups", err)
+	} else {
+		data.Backup.SetBackups(backups)
+	}
+	if ConfigBool(dp.config, "collect_ltr_backups") {
+		operations, err := dp.GetLTRBackupOperations(*server.ID)
+		if err != nil {
+			dp.collectionWarning(data, "long-term retention backups", err)
+		} else {
+			data.Backup.LongTermRetention = operations
+		}
+	}
+
+	// Parameters collected before a failed page are kept, so one bad page doesn't hide the rest.
+	configurations, configurationsErr := dp.GetServerConfigurations(server)
+	if configurationsErr != nil {
+		dp.collectionWarning(data, "server parameters", configurationsErr)
+	}
+	if len(configurations) > 0 {
+		data.Configurations = configurations
+	}
+	data.Logging = NewLoggingSettings(configurations)
+	data.ConnectionPooling = NewConnectionPooling(configurations)
+	dp.collectExtensions(server, data, configurationsErr)
+
+	// As with the extension allowlist, the SSL parameters are only fetched on their own when listing failed before
+	// reaching them.
+	data.SSL = SSLPostureFromConfigurations(data.Configurations)
+	_, requireSecureTransportListed := data.Configurations[requireSecureTransportParameter]
+	_, minProtocolVersionListed := data.Configurations[sslMinProtocolVersionParameter]
+	if configurationsErr != nil && (!requireSecureTransportListed || !minProtocolVersionListed) {
+		data.SSL = dp.GetSSLPosture(server)
+	}
+	if !data.SSL.Determinable {
+		dp.collectionWarning(data, "ssl posture", errors.New(data.SSL.Reason))
+	}
+	if required, known := data.SSL.SecureTransportRequired(); known {
+		data.Facts.RequireSecureTransport = BoolAddressed(required)
+	}
+	if data.SSL.MinProtocolVersion != nil && *data.SSL.MinProtocolVersion != "" {
+		data.Facts.SSLMinProtocolVersion = data.SSL.MinProtocolVersion
+	}
+
+	dp.collectAdminLoginFact(data)
+	dp.collectTagFacts(data)
+
+	if rules, err := ParseStorageTierRules(ConfigString(dp.config, "storage_tier_rules", defaultStorageTierRules)); err != nil {
+		dp.logger.Warn("invalid storage tier rules", "error", err)
+	} else if known, mismatch := storageTierMismatch(rules, server, extended); known {
+		data.Facts.StorageTierMismatch = BoolAddressed(mismatch != "")
+		data.storageMismatch = StringAddressed(mismatch)
+	}
+
+	// Replicas can't have replicas of their own, so they are only listed for primaries.
+	data.Replication = NewReplication(extended)
+	if !data.Replication.IsReplica {
+		replicas, err := dp.GetReplicas(*server.ID)
+		if err != nil {
+			dp.collectionWarning(data, "replicas", err)
+		} else {
+			data.Replicas = replicas
+			data.Replication.SetReplicas(replicas)
+			data.Facts.HasCrossRegionReplica = BoolAddressed(HasCrossRegionReplica(*server.Location, replicas))
+		}
+	}
+	dp.collectRegionPairs(data)
+
+	data.AuthConfig = NewAuthConfig(extended)
+	if data.AuthConfig != nil {
+		data.Facts.EntraAuthEnabled = BoolAddressed(IsAuthEnabled(data.AuthConfig.ActiveDirectoryAuth))
+		data.Facts.PasswordAuthEnabled = BoolAddressed(IsAuthEnabled(data.AuthConfig.PasswordAuth))
+		// Password only servers still rely on the administrator login alone, without any Entra ID identities.
+		data.Facts.PasswordOnlyAuth = BoolAddressed(*data.Facts.PasswordAuthEnabled && !*data.Facts.EntraAuthEnabled)
+	}
+
+	administrators, err := dp.GetAdministrators(*server.ID)
+	if err != nil {
+		dp.collectionWarning(data, "administrators", err)
+	} else {
+		data.Administrators = administrators
+		data.Facts.HasEntraAdministrator = BoolAddressed(len(administrators) > 0)
+	}
+
+	data.Network = NewNetworkConfig(server, extended)
+	dp.collectNetworkFacts(data, extended != nil)
+
+	dp.collectVNetIntegration(data)
+
+	// VNet integrated servers are reached through their subnet and have no firewall rules to list.
+	if data.Network.VNetIntegrated {
+		data.FirewallRules = make([]FirewallRule, 0)
+		data.Facts.AllowAllFirewallRule = BoolAddressed(false)
+		data.Facts.AllowAzureServicesRule = BoolAddressed(false)
+	} else {
+		firewallRules, err := dp.GetFirewallRules(*server.ID)
+		dp.collectFirewallRules(data, firewallRules, err)
+	}
+	data.DataEncryption = NewDataEncryption(extended)
+	data.ManagedIdentities = NewManagedIdentities(extended)
+	if data.DataEncryption != nil && data.DataEncryption.CustomerManaged() && data.ManagedIdentities != nil {
+		assigned := data.ManagedIdentities.HasUserAssigned(data.DataEncryption.PrimaryUserAssignedIdentityID)
+		if id := data.DataEncryption.GeoBackupUserAssignedIdentityID; id != "" {
+			assigned = assigned && data.ManagedIdentities.HasUserAssigned(id)
+		}
+		data.Facts.EncryptionIdentityAssigned = BoolAddressed(assigned)
+	}
+	dp.collectEncryptionKeys(data)
+
+	threatProtection, err := dp.GetThreatProtection(*server.ID)
+	if err != nil {
+		dp.collectionWarning(data, "threat protection", err)
+	} else {
+		data.ThreatProtection = threatProtection
+		data.Facts.ThreatProtectionEnabled = BoolAddressed(threatProtection.Enabled())
+	}
+
+	dp.collectMigrations(data)
+
+	databases, err := dp.GetDatabases(*server.ID)
+	if err != nil {
+		dp.collectionWarning(data, "databases", err)
+	} else {
+		data.Databases = databases
+	}
+
+	dp.collectDatabaseProbes(data)
+
+	virtualEndpoints, err := dp.GetVirtualEndpoints(*server.ID)
+	if err != nil {
+		dp.collectionWarning(data, "virtual endpoints", err)
+	} else {
+		data.VirtualEndpoints = virtualEndpoints
+		data.Facts.HasVirtualEndpoint = BoolAddressed(len(virtualEndpoints) > 0)
+	}
+
+	dp.collectResourceData(data)
+
+	return data
+}
+
+// collectSingleServerData builds the policy input for a legacy single server.
+func (dp *AzureDataProcessor) collectSingleServerData(data *ServerData) *ServerData {
+	data.Facts = DeriveServerFacts(data.Server, nil)
+	data.Storage = NewStorageConfig(data.Server, nil)
+
+	single, err := dp.GetSingleServer(*data.ID)
+	if err != nil {
+		dp.collectionWarning(data, "single server properties", err)
+		data.Backup = NewBackupConfig(data.Server)
+	} else {
+		data.SingleServer = NewSingleServerProperties(single)
+		data.Backup = NewSingleServerBackupConfig(single)
+	}
+	if data.SingleServer != nil {
+		if required, known := data.SingleServer.SecureTransportRequired(); known {
+			data.Facts.RequireSecureTransport = BoolAddressed(required)
+		}
+		if version := data.SingleServer.MinProtocolVersion(); version != "" {
+			data.Facts.SSLMinProtocolVersion = StringAddressed(version)
+		}
+	}
+	data.Network = NewSingleServerNetworkConfig(data.SingleServer)
+	dp.collectNetworkFacts(data, false)
+
+	dp.collectAdminLoginFact(data)
+	dp.collectTagFacts(data)
+
+	firewallRules, err := dp.GetSingleServerFirewallRules(*data.ID)
+	dp.collectFirewallRules(data, firewallRules, err)
+	dp.collectResourceData(data)
+
+	return data
+}
+
+// collectClusterData builds the policy input for an Azure Cosmos DB for PostgreSQL cluster.
+func (dp *AzureDataProcessor) collectClusterData(data *ServerData) *ServerData {
+	data.Facts = DeriveServerFacts(data.Server, nil)
+
+	cluster, err := dp.GetCluster(*data.ID)
+	if err != nil {
+		dp.collectionWarning(data, "cluster properties", err)
+	} else {
+		data.Cluster = NewClusterProperties(cluster)
+		data.Facts.HighAvailabilityEnabled = BoolAddressed(data.Cluster.HighAvailabilityEnabled)
+	}
+	data.Network = NewClusterNetworkConfig(data.Cluster)
+	dp.collectNetworkFacts(data, false)
+
+	dp.collectAdminLoginFact(data)
+	dp.collectTagFacts(data)
+
+	firewallRules, err := dp.GetClusterFirewallRules(*data.ID)
+	dp.collectFirewallRules(data, firewallRules, err)
+	dp.collectResourceData(data)
+
+	return data
+}
+
+// collectArcData builds the policy input for an Arc-enabled PostgreSQL instance. Its network, firewall and
+// PostgreSQL settings are managed in Kubernetes rather than Azure, so only its Azure resource data is collected.
+func (dp *AzureDataProcessor) collectArcData(data *ServerData) *ServerData {
+	data.Facts = &ServerFacts{}
+
+	instance, err := dp.GetArcInstance(*data.ID)
+	if err != nil {
+		dp.collectionWarning(data, "arc instance properties", err)
+	} else {
+		data.Arc = NewArcProperties(instance)
+	}
+
+	dp.collectTagFacts(data)
+	dp.collectResourceData(data)
+
+	return data
+}
+
+func (dp *AzureDataProcessor) collectLocationCapabilities(data *ServerData) {
+	if data.Location == nil {
+		return
+	}
+	idparts, err := ParseAzureResourceID(*data.ID)
+	if err != nil {
+		dp.collectionWarning(data, "location capabilities", err)
+		return
+	}
+	capabilities, err := dp.GetLocationCapabilities(idparts.SubscriptionID(), *data.Location)
+	if err != nil {
+		dp.collectionWarning(data, "location capabilities", err)
+		return
+	}
+	data.LocationCapabilities = capabilities
+	data.Facts.HighAvailabilityAvailable = BoolAddressed(capabilities.HighAvailabilityAvailable())
+	data.Facts.ZoneRedundantHAAvailable = BoolAddressed(capabilities.ZoneRedundantHASupported)
+}
+
+// collectRegionPairs adds Azure's region metadata to the replication topology, so data residency policies can check
+// that replicas stay within a geography. The replication_outside_geography fact is only set when every region
+// involved could be resolved.
+func (dp *AzureDataProcessor) collectRegionPairs(data *ServerData) {
+	replication := data.Replication
+	if data.Location == nil || (!replication.IsReplica && len(data.Replicas) == 0) {
+		return
+	}
+	idparts, err := ParseAzureResourceID(*data.ID)
+	if err != nil {
+		dp.collectionWarning(data, "region metadata", err)
+		return
+	}
+	region, err := dp.GetRegion(idparts.SubscriptionID(), *data.Location)
+	if err != nil {
+		dp.collectionWarning(data, "region metadata", err)
+		return
+	}
+	if region == nil {
+		return
+	}
+	replication.Region = region
+
+	known, outside := true, false
+	for i := range data.Replicas {
+		replica := &data.Replicas[i]
+		replicaRegion, err := dp.GetRegion(idparts.SubscriptionID(), replica.Location)
+		if err != nil || replicaRegion == nil {
+			known = false
+			continue
+		}
+		replica.Geography = replicaRegion.Geography
+		replica.PairedRegion = region.IsPairedWith(replica.Location)
+		outside = outside || replica.Geography != region.Geography
+	}
+
+	if replication.IsReplica && replication.SourceServerID != "" {
+		known = false
+		location, err := dp.GetServerLocation(replication.SourceServerID)
+		if err != nil {
+			dp.collectionWarning(data, "replication source region", err)
+		} else if location != "" {
+			replication.SourceLocation = location
+			replication.SourcePairedRegion = BoolAddressed(region.IsPairedWith(location))
+			if sourceRegion, err := dp.GetRegion(idparts.SubscriptionID(), location); err == nil && sourceRegion != nil {
+				replication.SourceGeography = sourceRegion.Geography
+				outside = sourceRegion.Geography != region.Geography
+				known = true
+			}
+		}
+	}
+	if known {
+		data.Facts.ReplicationOutsideGeography = BoolAddressed(outside)
+	}
+}
+
+// collectDatabaseProbes connects to the server when db_connect is enabled, lists its roles and runs the db_probes.
+// The server must be reachable from where the plugin runs, which private servers often aren't.
+func (dp *AzureDataProcessor) collectDatabaseProbes(data *ServerData) {
+	if !ConfigBool(dp.config, "db_connect") {
+		return
+	}
+	if data.Properties == nil || data.Properties.FullyQualifiedDomainName == nil {
+		dp.collectionWarning(data, "database connection", errors.New("the server has no host name"))
+		return
+	}
+
+	conn, err := dp.connectDatabase(*data.Properties.FullyQualifiedDomainName)
+	if err != nil {
+		dp.collectionWarning(data, "database connection", err)
+		return
+	}
+	defer func() {
+		_ = conn.Close(dp.ctx)
+	}()
+
+	roles, err := dp.GetDatabaseRoles(conn)
+	if err != nil {
+		dp.collectionWarning(data, "database roles", err)
+	} else {
+		data.DatabaseRoles = roles
+		data.Facts.SuperuserLoginRole = BoolAddressed(HasSuperuserLogin(roles))
+		data.Facts.NonExpiringLoginRole = BoolAddressed(HasNonExpiringLogin(roles))
+	}
+
+	// The probes were validated with the rest of the configuration.
+	probes, _ := ParseSQLProbes(dp.config)
+	if len(probes) > 0 {
+		data.SQLProbes = dp.RunSQLProbes(conn, probes)
+	}
+}
+
+func (dp *AzureDataProcessor) collectMigrations(data *ServerData) {
+	if !ConfigBool(dp.config, "collect_migrations") {
+		return
+	}
+	// The value was validated with the rest of the configuration.
+	days, _ := ConfigInt(dp.config, "stalled_migration_days", defaultStalledMigrationDays)
+	migrations, err := dp.GetMigrations(*data.ID, time.Duration(days)*24*time.Hour)
+	if err != nil {
+		dp.collectionWarning(data, "migrations", err)
+		return
+	}
+	data.Migrations = migrations
+	inProgress, stalled := false, false
+	for _, migration := range migrations {
+		inProgress = inProgress || migration.InProgress()
+		stalled = stalled || migration.Stalled
+	}
+	data.Facts.MigrationInProgress = BoolAddressed(inProgress)
+	data.Facts.StalledMigration = BoolAddressed(stalled)
+}
+
+// collectResourceData collects the data every kind of server has as an Azure resource, rather than as a PostgreSQL
+// server.
+func (dp *AzureDataProcessor) collectResourceData(data *ServerData) {
+	dp.collectDiagnosticSettings(data)
+	dp.collectLocks(data)
+	dp.collectAdvisorRecommendations(data)
+	dp.collectPolicyStates(data)
+	dp.collectDefenderAssessments(data)
+	dp.collectRoleAssignments(data)
+	dp.collectActivityLog(data)
+}
+
+// collectTagFacts checks the server's tags against required_tags, which are matched case-insensitively.
+func (dp *AzureDataProcessor) collectTagFacts(data *ServerData) {
+	required := ConfigList(dp.config, "required_tags", nil)
+	if len(required) == 0 {
+		return
+	}
+	for i := range required {
+		required[i] = strings.ToLower(required[i])
+	}
+	data.MissingTags = MissingTags(data.NormalisedTags, required)
+	data.Facts.MissingRequiredTags = BoolAddressed(len(data.MissingTags) > 0)
+}
+
+// collectExtensions reads the extension allowlist from the listed server parameters, only fetching azure.extensions
+// on its own when listing them failed before reaching it.
+func (dp *AzureDataProcessor) collectExtensions(server *armpostgresqlflexibleservers.Server, data *ServerData, listErr error) {
+	extensions := ParseExtensionAllowlist(data.Configurations[extensionsParameter].Value)
+	if _, listed := data.Configurations[extensionsParameter]; !listed && listErr != nil {
+		var err error
+		if extensions, err = dp.GetExtensionAllowlist(server); err != nil {
+			dp.collectionWarning(data, "extension allowlist", err)
+			return
+		}
+	}
+	data.Extensions = extensions
+	dp.collectExtensionFacts(data)
+}
+
+// collectExtensionFacts checks the extension allowlist against prohibited_extensions and required_extensions.
+func (dp *AzureDataProcessor) collectExtensionFacts(data *ServerData) {
+	prohibited := ConfigList(dp.config, "prohibited_extensions", nil)
+	required := ConfigList(dp.config, "required_extensions", nil)
+	data.Extensions.Check(prohibited, required)
+	if len(prohibited) > 0 {
+		data.Facts.ProhibitedExtensionAllowed = BoolAddressed(len(data.Extensions.Prohibited) > 0)
+	}
+	if len(required) > 0 {
+		data.Facts.RequiredExtensionNotAllowed = BoolAddressed(len(data.Extensions.MissingRequired) > 0)
+	}
+}
+
+func (dp *AzureDataProcessor) collectAdminLoginFact(data *ServerData) {
+	if data.Properties != nil && data.Properties.AdministratorLogin != nil {
+		discouraged := ConfigList(dp.config, "discouraged_admin_logins", defaultDiscouragedAdminLogins)
+		data.Facts.DiscouragedAdminLogin = BoolAddressed(IsDiscouragedAdminLogin(*data.Properties.AdministratorLogin, discouraged))
+	}
+}
+
+// collectNetworkFacts derives the network facts. Private endpoints are only known when they could be collected.
+func (dp *AzureDataProcessor) collectNetworkFacts(data *ServerData, privateEndpointsKnown bool) {
+	if enabled, known := data.Network.PublicNetworkAccessEnabled(); known {
+		data.Facts.PublicNetworkAccessEnabled = BoolAddressed(enabled)
+	}
+	if privateEndpointsKnown {
+		data.Facts.HasPrivateEndpoint = BoolAddressed(data.Network.HasApprovedPrivateEndpoint())
+		data.Facts.UnapprovedPrivateEndpoint = BoolAddressed(!data.Network.AllPrivateEndpointsApproved())
+	}
+	if privateOnly, known := data.Network.PrivateAccessOnly(privateEndpointsKnown); known {
+		data.Facts.PrivateAccessOnly = BoolAddressed(privateOnly)
+	}
+}
+
+// collectVNetIntegration resolves the delegated subnet and private DNS zone of a VNet integrated server. They can
+// live in another subscription, which the plugin's credential may not be able to read.
+func (dp *AzureDataProcessor) collectVNetIntegration(data *ServerData) {
+	if id := data.Network.DelegatedSubnetResourceID; id != nil {
+		subnet, err := dp.GetSubnet(*id)
+		if err != nil {
+			dp.collectionWarning(data, "delegated subnet", err)
+		} else {
+			data.Network.Subnet = subnet
+		}
+	}
+
+	if id := data.Network.PrivateDNSZoneResourceID; id != nil {
+		zone, err := dp.GetPrivateDNSZone(*id)
+		if err != nil {
+			dp.collectionWarning(data, "private DNS zone", err)
+		} else {
+			data.Network.PrivateDNSZone = zone
+			links, err := dp.GetVirtualNetworkLinks(*id)
+			if err != nil {
+				dp.collectionWarning(data, "private DNS zone virtual network links", err)
+			} else {
+				zone.VirtualNetworkLinks = links
+			}
+		}
+	}
+
+	// The server's name only resolves from its own virtual network when the zone is linked to it.
+	if zone, subnet := data.Network.PrivateDNSZone, data.Network.Subnet; zone != nil && zone.VirtualNetworkLinks != nil && subnet != nil && subnet.VirtualNetworkID != "" {
+		data.Facts.PrivateDNSZoneLinked = BoolAddressed(zone.LinksVirtualNetwork(subnet.VirtualNetworkID))
+	}
+}
+
+// collectEncryptionKeys reads the Key Vault metadata of a customer-managed key.
+func (dp *AzureDataProcessor) collectEncryptionKeys(data *ServerData) {
+	encryption := data.DataEncryption
+	if encryption == nil {
+		return
+	}
+	data.Facts.CustomerManagedKey = BoolAddressed(encryption.CustomerManaged())
+	if !encryption.CustomerManaged() {
+		return
+	}
+
+	client := NewKeyVaultClient(dp.credential, dp.clientOptions)
+	if encryption.PrimaryKeyURI != "" {
+		key, err := client.GetKey(dp.ctx, encryption.PrimaryKeyURI)
+		if err != nil {
+			dp.collectionWarning(data, "encryption key", err)
+		} else {
+			encryption.PrimaryKey = key
+			data.Facts.EncryptionKeyExpired = BoolAddressed(key.Expired(time.Now()))
+			if key.RotationEnabled != nil {
+				data.Facts.EncryptionKeyRotation = BoolAddressed(*key.RotationEnabled)
+			}
+		}
+	}
+	if encryption.GeoBackupKeyURI != "" {
+		key, err := client.GetKey(dp.ctx, encryption.GeoBackupKeyURI)
+		if err != nil {
+			dp.collectionWarning(data, "geo-backup encryption key", err)
+		} else {
+			encryption.GeoBackupKey = key
+			data.Facts.GeoBackupKeyExpired = BoolAddressed(key.Expired(time.Now()))
+		}
+	}
+}
+
+func (dp *AzureDataProcessor) collectFirewallRules(data *ServerData, rules []FirewallRule, err error) {
+	if err != nil {
+		dp.collectionWarning(data, "firewall rules", err)
+		return
+	}
+	data.FirewallRules = rules
+	data.Facts.AllowAllFirewallRule = BoolAddressed(HasAllowAllFirewallRule(rules))
+	data.Facts.AllowAzureServicesRule = BoolAddressed(HasAllowAzureServicesFirewallRule(rules))
+}
+
+func (dp *AzureDataProcessor) collectDiagnosticSettings(data *ServerData) {
+	settings, err := dp.GetDiagnosticSettings(*data.ID)
+	if err != nil {
+		dp.collectionWarning(data, "diagnostic settings", err)
+		return
+	}
+	for i := range settings {
+		if settings[i].WorkspaceID == "" {
+			continue
+		}
+		workspace, err := dp.GetLogAnalyticsWorkspace(settings[i].WorkspaceID)
+		if err != nil {
+			dp.collectionWarning(data, "log analytics workspace", err)
+			continue
+		}
+		settings[i].Workspace = workspace
+	}
+	data.DiagnosticSettings = settings
+	data.Facts.HasDiagnosticSettings = BoolAddressed(len(settings) > 0)
+	data.Facts.LogForwardingEnabled = BoolAddressed(HasLogForwarding(settings))
+	data.Facts.MetricForwardingEnabled = BoolAddressed(HasMetricForwarding(settings))
+}
+
+func (dp *AzureDataProcessor) collectLocks(data *ServerData) {
+	if !ConfigBool(dp.config, "collect_locks") {
+		return
+	}
+	locks, err := dp.GetManagementLocks(*data.ID)
+	if err != nil {
+		dp.collectionWarning(data, "management locks", err)
+	} else {
+		data.Locks = locks
+		data.Facts.HasDeleteLock = BoolAddressed(HasDeleteLock(locks))
+	}
+}
+
+func (dp *AzureDataProcessor) collectAdvisorRecommendations(data *ServerData) {
+	if !ConfigBool(dp.config, "collect_advisor_recommendations") {
+		return
+	}
+	recommendations, err := dp.GetAdvisorRecommendations(*data.ID)
+	if err != nil {
+		dp.collectionWarning(data, "advisor recommendations", err)
+	} else {
+		data.AdvisorRecommendations = recommendations
+	}
+}
+
+func (dp *AzureDataProcessor) collectPolicyStates(data *ServerData) {
+	if !ConfigBool(dp.config, "collect_policy_states") {
+		return
+	}
+	states, err := dp.GetPolicyStates(*data.ID)
+	if err != nil {
+		dp.collectionWarning(data, "azure policy states", err)
+		return
+	}
+	data.PolicyStates = states
+	data.Facts.AzurePolicyNonCompliant = BoolAddressed(HasNonCompliantPolicyState(states))
+}
+
+func (dp *AzureDataProcessor) collectDefenderAssessments(data *ServerData) {
+	if !ConfigBool(dp.config, "collect_defender_assessments") {
+		return
+	}
+	assessments, err := dp.GetDefenderAssessments(*data.ID)
+	if err != nil {
+		dp.collectionWarning(data, "defender assessments", err)
+		return
+	}
+	data.DefenderAssessments = assessments
+	unhealthy := false
+	for _, assessment := range assessments {
+		unhealthy = unhealthy || assessment.Unhealthy()
+	}
+	data.Facts.DefenderUnhealthy = BoolAddressed(unhealthy)
+}
+
+func (dp *AzureDataProcessor) collectRoleAssignments(data *ServerData) {
+	if !ConfigBool(dp.config, "collect_role_assignments") {
+		return
+	}
+	assignments, err := dp.GetRoleAssignments(*data.ID, ConfigBool(dp.config, "include_inherited_role_assignments"))
+	if err != nil {
+		dp.collectionWarning(data, "role assignments", err)
+		return
+	}
+	data.RoleAssignments = assignments
+	privileged := false
+	for _, assignment := range assignments {
+		privileged = privileged || (!assignment.Inherited && assignment.Privileged())
+	}
+	data.Facts.PrivilegedRoleAtServerScope = BoolAddressed(privileged)
+}
+
+func (dp *AzureDataProcessor) collectActivityLog(data *ServerData) {
+	// The value was validated with the rest of the configuration.
+	days, _ := ConfigInt(dp.config, "activity_log_days", 0)
+	if days <= 0 {
+		return
+	}
+	operations, err := dp.GetActivityLogOperations(*data.ID, days)
+	if err != nil {
+		dp.collectionWarning(data, "activity log", err)
+	} else {
+		data.ActivityLog = operations
+	}
+}
+
+// collectionWarning logs a failed optional collection and records it against the server.
+func (dp *AzureDataProcessor) collectionWarning(data *ServerData, collection string, err error) {
+	dp.logger.Warn("unable to collect "+collection, "server", *data.ID, "error", err)
+	data.warnings = append(data.warnings, CollectionWarning{
+		Collection: collection,
+		Error:      err,
+	})
+}
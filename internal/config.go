@@ -0,0 +1,55 @@
+package internal
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ConfigString reads a config value, returning the fallback when the key is unset or empty.
+func ConfigString(config map[string]string, key string, fallback string) string {
+	if value := strings.TrimSpace(config[key]); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// ConfigBool reads a boolean config value, treating a missing or unparseable value as false.
+func ConfigBool(config map[string]string, key string) bool {
+	value, err := strconv.ParseBool(strings.TrimSpace(config[key]))
+	if err != nil {
+		return false
+	}
+	return value
+}
+
+// ConfigInt reads an integer config value, returning the fallback when the key is unset.
+func ConfigInt(config map[string]string, key string, fallback int) (int, error) {
+	raw := strings.TrimSpace(config[key])
+	if raw == "" {
+		return fallback, nil
+	}
+
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be an integer: %w", key, err)
+	}
+	return value, nil
+}
+
+// ConfigList reads a comma separated config value, trimming each entry and dropping empty ones.
+// The fallback is returned when the key is unset.
+func ConfigList(config map[string]string, key string, fallback []string) []string {
+	raw, ok := config[key]
+	if !ok {
+		return fallback
+	}
+
+	values := make([]string, 0)
+	for _, value := range strings.Split(raw, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
@@ -0,0 +1,253 @@
+package internal
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/postgresql/armpostgresqlflexibleservers"
+)
+
+const extensionsParameter = "azure.extensions"
+
+// ExtensionAllowlist is the parsed form of the azure.extensions server parameter, which governs the
+// extensions that may be created on the server.
+type ExtensionAllowlist struct {
+	// Set is false when the parameter is unset or empty, meaning no allowlist has been configured.
+	Set        bool     `json:"set"`
+	Raw        string   `json:"raw"`
+	Extensions []string `json:"extensions"`
+	// Prohibited are the allowlisted prohibited_extensions, only set when prohibited_extensions is configured.
+	Prohibited []string `json:"prohibited,omitempty"`
+	// MissingRequired are the required_extensions that aren't allowlisted, only set when required_extensions is
+	// configured.
+	MissingRequired []string `json:"missing_required,omitempty"`
+}
+
+// ParseExtensionAllowlist parses the comma separated azure.extensions value into a sorted, de-duplicated list of
+// lower case extension names.
+func ParseExtensionAllowlist(raw string) *ExtensionAllowlist {
+	allowlist := &ExtensionAllowlist{
+		Raw:        raw,
+		Extensions: make([]string, 0),
+	}
+
+	seen := map[string]bool{}
+	for _, extension := range strings.Split(raw, ",") {
+		extension = strings.ToLower(strings.TrimSpace(extension))
+		if extension == "" || seen[extension] {
+			continue
+		}
+		seen[extension] = true
+		allowlist.Extensions = append(allowlist.Extensions, extension)
+	}
+	sort.Strings(allowlist.Extensions)
+
+	allowlist.Set = len(allowlist.Extensions) > 0
+	return allowlist
+}
+
+// Allows reports whether the extension is allowlisted, ignoring case.
+func (a *ExtensionAllowlist) Allows(extension string) bool {
+	return slices.Contains(a.Extensions, strings.ToLower(strings.TrimSpace(extension)))
+}
+
+// Check records which of the prohibited extensions are allowlisted and which of the required ones are not.
+func (a *ExtensionAllowlist) Check(prohibited []string, required []string) {
+	if len(prohibited) > 0 {
+		a.Prohibited = make([]string, 0)
+		for _, extension := range prohibited {
+			if a.Allows(extension) {
+				a.Prohibited = append(a.Prohibited, strings.ToLower(extension))
+			}
+		}
+	}
+	if len(required) > 0 {
+		a.MissingRequired = make([]string, 0)
+		for _, extension := range required {
+			if !a.Allows(extension) {
+				a.MissingRequired = append(a.MissingRequired, strings.ToLower(extension))
+			}
+		}
+	}
+}
+
+// GetServerConfiguration fetches a single server parameter by name.
+func (dp *AzureDataProcessor) GetServerConfiguration(server *armpostgresqlflexibleservers.Server, name string) (*armpostgresqlflexibleservers.Configuration, error) {
+	idparts, err := ParseAzureResourceID(*server.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := armpostgresqlflexibleservers.NewConfigurationsClient(idparts.SubscriptionID(), dp.credential, dp.clientOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Get(dp.ctx, idparts.ResourceGroup(), *server.Name, name, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &resp.Configuration, nil
+}
+
+// ServerConfiguration is the current value of a server parameter.
+type ServerConfiguration struct {
+	Value        string `json:"value"`
+	DefaultValue string `json:"default_value"`
+	// IsDefault is false when the value differs from the parameter's default.
+	IsDefault bool   `json:"is_default"`
+	Source    string `json:"source,omitempty"`
+	// DataType is Boolean, Integer, Numeric or Enumeration, telling policies how to compare the value.
+	DataType string `json:"data_type,omitempty"`
+	// PendingRestart is true when the value has been changed but only takes effect once the server restarts.
+	PendingRestart bool `json:"pending_restart"`
+}
+
+// GetServerConfigurations lists every parameter of a server, keyed by parameter name.
+// If a page fails, the parameters collected so far are returned along with the error.
+func (dp *AzureDataProcessor) GetServerConfigurations(server *armpostgresqlflexibleservers.Server) (map[string]ServerConfiguration, error) {
+	idparts, err := ParseAzureResourceID(*server.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := armpostgresqlflexibleservers.NewConfigurationsClient(idparts.SubscriptionID(), dp.credential, dp.clientOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	configurations := map[string]ServerConfiguration{}
+	pager := client.NewListByServerPager(idparts.ResourceGroup(), *server.Name, nil)
+	for pager.More() {
+		page, err := pager.NextPage(dp.ctx)
+		if err != nil {
+			return configurations, err
+		}
+
+		for _, configuration := range page.Value {
+			if configuration.Name == nil {
+				continue
+			}
+
+			c := ServerConfiguration{}
+			if configuration.Properties != nil {
+				if configuration.Properties.Value != nil {
+					c.Value = *configuration.Properties.Value
+				}
+				if configuration.Properties.DefaultValue != nil {
+					c.DefaultValue = *configuration.Properties.DefaultValue
+				}
+				if configuration.Properties.Source != nil {
+					c.Source = *configuration.Properties.Source
+				}
+				if configuration.Properties.DataType != nil {
+					c.DataType = string(*configuration.Properties.DataType)
+				}
+				if configuration.Properties.IsConfigPendingRestart != nil {
+					c.PendingRestart = *configuration.Properties.IsConfigPendingRestart
+				}
+			}
+			c.IsDefault = c.Value == c.DefaultValue
+			configurations[*configuration.Name] = c
+		}
+	}
+	return configurations, nil
+}
+
+// GetExtensionAllowlist fetches and parses the azure.extensions parameter for a server, for when the server
+// parameters couldn't be listed.
+func (dp *AzureDataProcessor) GetExtensionAllowlist(server *armpostgresqlflexibleservers.Server) (*ExtensionAllowlist, error) {
+	configuration, err := dp.GetServerConfiguration(server, extensionsParameter)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := ""
+	if configuration.Properties != nil && configuration.Properties.Value != nil {
+		raw = *configuration.Properties.Value
+	}
+	return ParseExtensionAllowlist(raw), nil
+}
+
+const (
+	requireSecureTransportParameter = "require_secure_transport"
+	sslMinProtocolVersionParameter  = "ssl_min_protocol_version"
+)
+
+// SSLPosture records the server parameters that determine whether connections must use SSL/TLS.
+type SSLPosture struct {
+	RequireSecureTransport *string `json:"require_secure_transport,omitempty"`
+	MinProtocolVersion     *string `json:"ssl_min_protocol_version,omitempty"`
+	// Determinable is false when SSL enforcement could not be read, in which case Reason explains why.
+	Determinable bool   `json:"determinable"`
+	Reason       string `json:"reason,omitempty"`
+}
+
+// GetSSLPosture fetches the SSL related server parameters one by one, for when they couldn't be listed. Failures to
+// read them are recorded on the posture rather than returned, as an undeterminable posture is itself reported as
+// evidence.
+func (dp *AzureDataProcessor) GetSSLPosture(server *armpostgresqlflexibleservers.Server) *SSLPosture {
+	requireSecureTransport, err := dp.GetServerConfiguration(server, requireSecureTransportParameter)
+	if err != nil {
+		dp.logger.Warn("unable to fetch server parameter", "server", *server.ID, "parameter", requireSecureTransportParameter, "error", err)
+		return &SSLPosture{Reason: fmt.Sprintf("unable to read the %s parameter: %s", requireSecureTransportParameter, err)}
+	}
+
+	minProtocolVersion, err := dp.GetServerConfiguration(server, sslMinProtocolVersionParameter)
+	if err != nil {
+		dp.logger.Warn("unable to fetch server parameter", "server", *server.ID, "parameter", sslMinProtocolVersionParameter, "error", err)
+	}
+
+	return NewSSLPosture(requireSecureTransport, minProtocolVersion)
+}
+
+// NewSSLPosture builds the posture from the require_secure_transport and ssl_min_protocol_version parameters,
+// either of which may be nil when it couldn't be read. Without a require_secure_transport value the posture is
+// undeterminable.
+func NewSSLPosture(requireSecureTransport *armpostgresqlflexibleservers.Configuration, minProtocolVersion *armpostgresqlflexibleservers.Configuration) *SSLPosture {
+	posture := &SSLPosture{}
+	if requireSecureTransport == nil || requireSecureTransport.Properties == nil || requireSecureTransport.Properties.Value == nil {
+		posture.Reason = fmt.Sprintf("the %s parameter has no value", requireSecureTransportParameter)
+		return posture
+	}
+	posture.RequireSecureTransport = requireSecureTransport.Properties.Value
+	posture.Determinable = true
+
+	if minProtocolVersion != nil && minProtocolVersion.Properties != nil {
+		posture.MinProtocolVersion = minProtocolVersion.Properties.Value
+	}
+	return posture
+}
+
+// SSLPostureFromConfigurations builds the posture from the listed server parameters, treating a parameter that
+// wasn't listed as having no value.
+func SSLPostureFromConfigurations(configurations map[string]ServerConfiguration) *SSLPosture {
+	return NewSSLPosture(
+		listedConfiguration(configurations, requireSecureTransportParameter),
+		listedConfiguration(configurations, sslMinProtocolVersionParameter),
+	)
+}
+
+// listedConfiguration returns a listed server parameter in the SDK's form, or nil when it wasn't listed.
+func listedConfiguration(configurations map[string]ServerConfiguration, name string) *armpostgresqlflexibleservers.Configuration {
+	configuration, ok := configurations[name]
+	if !ok {
+		return nil
+	}
+	return &armpostgresqlflexibleservers.Configuration{
+		Name: StringAddressed(name),
+		Properties: &armpostgresqlflexibleservers.ConfigurationProperties{
+			Value: StringAddressed(configuration.Value),
+		},
+	}
+}
+
+// SecureTransportRequired reports whether require_secure_transport could be read, and if so whether it is on.
+func (p *SSLPosture) SecureTransportRequired() (bool, bool) {
+	if p.RequireSecureTransport == nil {
+		return false, false
+	}
+	return strings.EqualFold(*p.RequireSecureTransport, "on") || strings.EqualFold(*p.RequireSecureTransport, "true"), true
+}
@@ -0,0 +1,120 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/postgresql/armpostgresqlflexibleservers"
+)
+
+func configurationValue(value *string) *armpostgresqlflexibleservers.Configuration {
+	return &armpostgresqlflexibleservers.Configuration{
+		Properties: &armpostgresqlflexibleservers.ConfigurationProperties{Value: value},
+	}
+}
+
+func TestSSLPosture(t *testing.T) {
+	tests := []struct {
+		name                   string
+		requireSecureTransport *armpostgresqlflexibleservers.Configuration
+		minProtocolVersion     *armpostgresqlflexibleservers.Configuration
+		wantRequired           bool
+		wantDeterminable       bool
+		wantMinProtocolVersion string
+	}{
+		{
+			name: "no parameters",
+		},
+		{
+			name:               "no require_secure_transport",
+			minProtocolVersion: configurationValue(to.Ptr("TLSV1.2")),
+		},
+		{
+			name:                   "require_secure_transport without properties",
+			requireSecureTransport: &armpostgresqlflexibleservers.Configuration{},
+		},
+		{
+			name:                   "require_secure_transport without a value",
+			requireSecureTransport: configurationValue(nil),
+			minProtocolVersion:     configurationValue(to.Ptr("TLSV1.2")),
+		},
+		{
+			name:                   "on",
+			requireSecureTransport: configurationValue(to.Ptr("on")),
+			minProtocolVersion:     configurationValue(to.Ptr("TLSV1.2")),
+			wantRequired:           true,
+			wantDeterminable:       true,
+			wantMinProtocolVersion: "TLSV1.2",
+		},
+		{
+			name:                   "ON without ssl_min_protocol_version",
+			requireSecureTransport: configurationValue(to.Ptr("ON")),
+			wantRequired:           true,
+			wantDeterminable:       true,
+		},
+		{
+			name:                   "true",
+			requireSecureTransport: configurationValue(to.Ptr("true")),
+			minProtocolVersion:     configurationValue(nil),
+			wantRequired:           true,
+			wantDeterminable:       true,
+		},
+		{
+			name:                   "off",
+			requireSecureTransport: configurationValue(to.Ptr("off")),
+			minProtocolVersion:     configurationValue(to.Ptr("TLSV1.3")),
+			wantDeterminable:       true,
+			wantMinProtocolVersion: "TLSV1.3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			posture := NewSSLPosture(tt.requireSecureTransport, tt.minProtocolVersion)
+
+			required, determinable := posture.SecureTransportRequired()
+			if required != tt.wantRequired || determinable != tt.wantDeterminable {
+				t.Errorf("SecureTransportRequired() = (%v, %v), want (%v, %v)", required, determinable, tt.wantRequired, tt.wantDeterminable)
+			}
+			if posture.Determinable != tt.wantDeterminable {
+				t.Errorf("Determinable = %v, want %v", posture.Determinable, tt.wantDeterminable)
+			}
+			if !posture.Determinable && posture.Reason == "" {
+				t.Errorf("undeterminable posture has no reason")
+			}
+
+			minProtocolVersion := ""
+			if posture.MinProtocolVersion != nil {
+				minProtocolVersion = *posture.MinProtocolVersion
+			}
+			if minProtocolVersion != tt.wantMinProtocolVersion {
+				t.Errorf("MinProtocolVersion = %q, want %q", minProtocolVersion, tt.wantMinProtocolVersion)
+			}
+		})
+	}
+}
+
+func TestSSLPostureFromConfigurations(t *testing.T) {
+	posture := SSLPostureFromConfigurations(map[string]ServerConfiguration{
+		requireSecureTransportParameter: {Value: "on"},
+		sslMinProtocolVersionParameter:  {Value: "TLSV1.2"},
+		extensionsParameter:             {Value: "pg_stat_statements"},
+	})
+	if required, determinable := posture.SecureTransportRequired(); !required || !determinable {
+		t.Errorf("SecureTransportRequired() = (%v, %v), want (true, true)", required, determinable)
+	}
+	if posture.MinProtocolVersion == nil || *posture.MinProtocolVersion != "TLSV1.2" {
+		t.Errorf("MinProtocolVersion = %v, want TLSV1.2", posture.MinProtocolVersion)
+	}
+
+	posture = SSLPostureFromConfigurations(map[string]ServerConfiguration{
+		sslMinProtocolVersionParameter: {Value: "TLSV1.2"},
+	})
+	if posture.Determinable || posture.Reason == "" {
+		t.Errorf("posture without require_secure_transport = %+v, want undeterminable with a reason", posture)
+	}
+
+	if posture := SSLPostureFromConfigurations(nil); posture.Determinable {
+		t.Errorf("posture without parameters = %+v, want undeterminable", posture)
+	}
+}
@@ -0,0 +1,52 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/compliance-framework/agent/runner/proto"
+	"github.com/hashicorp/go-hclog"
+)
+
+// ControlMappings adds control framework labels to evidence, keyed by the policy package or built-in check named in
+// the evidence's _policy label, e.g. {"azure_postgres.ssl": {"cis": "4.3.1", "baseline": "DB-07"}}.
+type ControlMappings struct {
+	labels map[string]map[string]string
+}
+
+// ParseControlMappings parses the control_mappings JSON object. Unset means no mappings.
+func ParseControlMappings(config map[string]string) (*ControlMappings, error) {
+	mappings := &ControlMappings{
+		labels: map[string]map[string]string{},
+	}
+	if raw := config["control_mappings"]; raw != "" {
+		if err := json.Unmarshal([]byte(raw), &mappings.labels); err != nil {
+			return nil, fmt.Errorf("control_mappings must be a JSON object of label objects keyed by policy name: %w", err)
+		}
+	}
+	return mappings, nil
+}
+
+// Apply adds the labels mapped to the evidence's policy. Labels the evidence already carries are kept, so a mapping
+// can't overwrite the infrastructure labels, and evidence for a policy without a mapping is left untouched.
+func (m *ControlMappings) Apply(logger hclog.Logger, evidence *proto.Evidence) {
+	mapped, ok := m.labels[evidence.Labels["_policy"]]
+	if !ok {
+		return
+	}
+	if evidence.Labels == nil {
+		evidence.Labels = map[string]string{}
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(mapped)) {
+		if existing, ok := evidence.Labels[key]; ok {
+			if existing != mapped[key] {
+				logger.Warn("Ignoring control mapping label that conflicts with an evidence label", "policy", evidence.Labels["_policy"], "label", key)
+			}
+			continue
+		}
+		evidence.Labels[key] = mapped[key]
+	}
+}
@@ -0,0 +1,135 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"sync"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/hashicorp/go-hclog"
+)
+
+// CredentialCache holds the Azure credential for the lifetime of the plugin so that each evaluation cycle
+// reuses it rather than acquiring new tokens. The credential refreshes its own tokens as they near expiry,
+// so it is only rebuilt when the plugin is reconfigured with a different configuration.
+// It is safe for concurrent use.
+type CredentialCache struct {
+	mu         sync.Mutex
+	factory    CredentialFactory
+	credential azcore.TokenCredential
+	config     map[string]string
+}
+
+// CredentialFactory builds an Azure credential from the plugin configuration.
+type CredentialFactory func(config map[string]string) (azcore.TokenCredential, error)
+
+func NewCredentialCache() *CredentialCache {
+	return NewCredentialCacheWithFactory(DefaultCredentialFactory)
+}
+
+func NewCredentialCacheWithFactory(factory CredentialFactory) *CredentialCache {
+	return &CredentialCache{
+		factory: factory,
+	}
+}
+
+const (
+	AuthMethodDefault         = "default"
+	AuthMethodClientSecret    = "client_secret"
+	AuthMethodManagedIdentity = "managed_identity"
+)
+
+// DefaultCredentialFactory builds the credential selected by auth_method: a service principal secret for
+// client_secret, a managed identity for managed_identity, or the default Azure credential chain otherwise.
+// The credential authenticates against the cloud selected by the cloud config, through proxy_url when set.
+func DefaultCredentialFactory(config map[string]string) (azcore.TokenCredential, error) {
+	azureCloud, err := ParseCloud(config)
+	if err != nil {
+		return nil, err
+	}
+	clientOptions := azcore.ClientOptions{Cloud: azureCloud}
+
+	// Token requests go through the same proxy as every other Azure request.
+	transport, err := NewTransport(config)
+	if err != nil {
+		return nil, err
+	}
+	if transport != nil {
+		clientOptions.Transport = transport
+	}
+
+	switch method := ConfigString(config, "auth_method", AuthMethodDefault); method {
+	case AuthMethodClientSecret:
+		if err := requireConfig(config, "tenant_id", "client_id", "client_secret"); err != nil {
+			return nil, fmt.Errorf("auth_method %s: %w", method, err)
+		}
+		return azidentity.NewClientSecretCredential(config["tenant_id"], config["client_id"], config["client_secret"], &azidentity.ClientSecretCredentialOptions{
+			ClientOptions: clientOptions,
+		})
+	case AuthMethodManagedIdentity:
+		options := &azidentity.ManagedIdentityCredentialOptions{
+			ClientOptions: clientOptions,
+		}
+		if clientID := config["client_id"]; clientID != "" {
+			options.ID = azidentity.ClientID(clientID)
+		}
+		return azidentity.NewManagedIdentityCredential(options)
+	case AuthMethodDefault:
+		return azidentity.NewDefaultAzureCredential(&azidentity.DefaultAzureCredentialOptions{
+			ClientOptions: clientOptions,
+		})
+	default:
+		return nil, fmt.Errorf("unsupported auth_method %q, expected one of %s, %s or %s", method, AuthMethodDefault, AuthMethodClientSecret, AuthMethodManagedIdentity)
+	}
+}
+
+// requireConfig returns an error naming the first of the keys that is missing or empty.
+func requireConfig(config map[string]string, keys ...string) error {
+	for _, key := range keys {
+		if config[key] == "" {
+			return fmt.Errorf("missing required config key %s", key)
+		}
+	}
+	return nil
+}
+
+// Get returns the cached credential, building a new one when none exists yet or the configuration has changed.
+func (c *CredentialCache) Get(logger hclog.Logger, config map[string]string) (azcore.TokenCredential, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.credential != nil && maps.Equal(c.config, config) {
+		return c.credential, nil
+	}
+
+	cred, err := c.factory(config)
+	if err != nil {
+		logger.Error("unable to get Azure credentials", "error", err)
+		return nil, err
+	}
+	logger.Debug("Azure credentials obtained successfully")
+
+	c.credential = cred
+	c.config = maps.Clone(config)
+	return cred, nil
+}
+
+// AcquireToken requests a Resource Manager token for the configured cloud, so that a credential that can't
+// authenticate fails the run up front instead of failing every Azure call. The credential caches the token, so
+// the clients that follow reuse it.
+func AcquireToken(ctx context.Context, credential azcore.TokenCredential, config map[string]string) error {
+	azureCloud, err := ParseCloud(config)
+	if err != nil {
+		return err
+	}
+
+	audience := azureCloud.Services[cloud.ResourceManager].Audience
+	if _, err := credential.GetToken(ctx, policy.TokenRequestOptions{Scopes: []string{audience + "/.default"}}); err != nil {
+		return fmt.Errorf("unable to authenticate with Azure: %w", err)
+	}
+	return nil
+}
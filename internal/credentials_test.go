@@ -0,0 +1,106 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/hashicorp/go-hclog"
+)
+
+// fakeCredential is a credential that always returns the same token.
+type fakeCredential struct{}
+
+func (fakeCredential) GetToken(context.Context, policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	return azcore.AccessToken{Token: "token", ExpiresOn: time.Now().Add(time.Hour)}, nil
+}
+
+// countingFactory returns a credential factory that counts its calls and fails while err is set.
+func countingFactory(calls *atomic.Int32, err *error) CredentialFactory {
+	return func(map[string]string) (azcore.TokenCredential, error) {
+		calls.Add(1)
+		if *err != nil {
+			return nil, *err
+		}
+		return fakeCredential{}, nil
+	}
+}
+
+func TestCredentialCacheReusesCredential(t *testing.T) {
+	var calls atomic.Int32
+	var factoryErr error
+	cache := NewCredentialCacheWithFactory(countingFactory(&calls, &factoryErr))
+	config := map[string]string{"subscription_ids": "sub-a,sub-b,sub-c"}
+
+	// Each subscription is assessed with the same configuration, so they share one credential.
+	for range 3 {
+		if _, err := cache.Get(hclog.NewNullLogger(), config); err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := cache.Get(hclog.NewNullLogger(), map[string]string{"subscription_ids": "sub-a,sub-b,sub-c"}); err != nil {
+				t.Errorf("Get: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := calls.Load(); got != 1 {
+		t.Errorf("factory called %d times, want 1", got)
+	}
+}
+
+func TestCredentialCacheRebuildsOnConfigChange(t *testing.T) {
+	var calls atomic.Int32
+	var factoryErr error
+	cache := NewCredentialCacheWithFactory(countingFactory(&calls, &factoryErr))
+
+	for _, subscriptions := range []string{"sub-a", "sub-a,sub-b", "sub-a,sub-b"} {
+		if _, err := cache.Get(hclog.NewNullLogger(), map[string]string{"subscription_ids": subscriptions}); err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+	}
+
+	if got := calls.Load(); got != 2 {
+		t.Errorf("factory called %d times, want 2", got)
+	}
+}
+
+func TestCredentialCacheDoesNotCacheErrors(t *testing.T) {
+	var calls atomic.Int32
+	factoryErr := errors.New("no credential available")
+	cache := NewCredentialCacheWithFactory(countingFactory(&calls, &factoryErr))
+	config := map[string]string{"subscription_ids": "sub-a"}
+
+	cred, err := cache.Get(hclog.NewNullLogger(), config)
+	if !errors.Is(err, factoryErr) {
+		t.Fatalf("Get error = %v, want %v", err, factoryErr)
+	}
+	if cred != nil {
+		t.Errorf("Get returned a credential alongside an error")
+	}
+
+	// A failed build isn't remembered, so the next call tries the factory again.
+	factoryErr = nil
+	cred, err = cache.Get(hclog.NewNullLogger(), config)
+	if err != nil {
+		t.Fatalf("Get after recovery: %v", err)
+	}
+	if cred == nil {
+		t.Errorf("Get returned no credential after recovery")
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("factory called %d times, want 2", got)
+	}
+}
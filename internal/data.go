@@ -2,15 +2,21 @@ package internal
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"iter"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
-	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/postgresql/armpostgresqlflexibleservers"
 	policyManager "github.com/compliance-framework/agent/policy-manager"
 	"github.com/compliance-framework/agent/runner"
 	"github.com/compliance-framework/agent/runner/proto"
+	"github.com/google/uuid"
 	"github.com/hashicorp/go-hclog"
 )
 
@@ -19,21 +25,183 @@ type AzureDataProcessor struct {
 	logger    hclog.Logger
 	config    map[string]string
 	apiHelper runner.ApiHelper
+
+	credentials *CredentialCache
+	credential  azcore.TokenCredential
+	// clientOptions are shared by every Azure client created in the run.
+	clientOptions *arm.ClientOptions
+	armClientMu   sync.Mutex
+	armClient     *ARMClient
+	sinks         []EvidenceSink
+	// serverLister lists the servers to assess, defaulting to an AzureServerLister for the configured subscriptions.
+	serverLister PostgresServerLister
+	// newPolicyEvaluator builds the evaluator for each policy path, defaulting to the agent's policy processor.
+	newPolicyEvaluator PolicyEvaluatorFactory
+	// evidenceBatches numbers the batches of evidence written in the current run.
+	evidenceBatches atomic.Int64
+
+	maintenanceSchedule *MaintenanceSchedule
+	tenantIDsMu         sync.Mutex
+	tenantIDs           map[string]string
+	workspacesMu        sync.Mutex
+	workspaces          map[string]workspaceResult
+	roleNamesMu         sync.Mutex
+	roleNames           map[string]string
+	capabilitiesMu      sync.Mutex
+	capabilities        map[string]capabilitiesResult
+	regionsMu           sync.Mutex
+	regions             map[string]regionsResult
+
+	// summary describes the outcome of the last call to Process.
+	summary *RunSummary
 }
 
-func NewAzureDataProcessor(ctx context.Context, logger hclog.Logger, config map[string]string, apiHelper runner.ApiHelper) *AzureDataProcessor {
+func NewAzureDataProcessor(ctx context.Context, logger hclog.Logger, config map[string]string, apiHelper runner.ApiHelper, credentials *CredentialCache) *AzureDataProcessor {
 	return &AzureDataProcessor{
-		ctx:       ctx,
-		logger:    logger,
-		config:    config,
-		apiHelper: apiHelper,
+		ctx:          ctx,
+		logger:       logger,
+		config:       config,
+		apiHelper:    apiHelper,
+		credentials:  credentials,
+		tenantIDs:    map[string]string{},
+		workspaces:   map[string]workspaceResult{},
+		roleNames:    map[string]string{},
+		capabilities: map[string]capabilitiesResult{},
+		regions:      map[string]regionsResult{},
 	}
 }
 
+// SetServerLister replaces the source of servers to assess, e.g. with a fake in tests.
+func (dp *AzureDataProcessor) SetServerLister(lister PostgresServerLister) {
+	dp.serverLister = lister
+}
+
+// PolicyEvaluator evaluates a policy path against a server's data, returning the resulting evidence.
+type PolicyEvaluator interface {
+	GenerateResults(ctx context.Context, policyPath string, data interface{}) ([]*proto.Evidence, error)
+}
+
+// PolicyEvaluatorFactory builds the evaluator for a server's evidence context.
+type PolicyEvaluatorFactory func(logger hclog.Logger, ec *EvidenceContext) PolicyEvaluator
+
+// NewPolicyProcessor builds the agent's policy processor for a server's evidence context.
+func NewPolicyProcessor(logger hclog.Logger, ec *EvidenceContext) PolicyEvaluator {
+	return policyManager.NewPolicyProcessor(
+		logger,
+		ec.labels,
+		ec.subjects,
+		ec.components,
+		ec.inventory,
+		ec.actors,
+		ec.activities,
+	)
+}
+
+// SetPolicyEvaluatorFactory replaces how policy evaluators are built, e.g. with a fake in tests.
+func (dp *AzureDataProcessor) SetPolicyEvaluatorFactory(factory PolicyEvaluatorFactory) {
+	dp.newPolicyEvaluator = factory
+}
+
 // Get the data from Azure, evaluate that data against policies and send to the API
 func (dp *AzureDataProcessor) Process(policyPaths []string) (proto.ExecutionStatus, error) {
 	evalStatus := proto.ExecutionStatus_SUCCESS
-	var accumulatedErrors error
+	errs := NewErrorAggregator()
+	metrics := newRunMetrics()
+	dp.evidenceBatches.Store(0)
+
+	// The credential is subscription agnostic, so it is resolved once up front and shared by every client in the
+	// run rather than acquiring tokens per subscription or per server. A token is requested straight away so a
+	// credential that can't authenticate stops the run before any server is listed.
+	cred, err := dp.credentials.Get(dp.logger, dp.config)
+	if err != nil {
+		return proto.ExecutionStatus_FAILURE, fmt.Errorf("unable to get Azure credentials: %w", err)
+	}
+	if err := AcquireToken(dp.ctx, cred, dp.config); err != nil {
+		dp.logger.Error("Azure credentials failed to authenticate, skipping the run", "error", err)
+		return proto.ExecutionStatus_FAILURE, err
+	}
+	dp.credential = cred
+
+	dp.clientOptions, err = NewClientOptions(dp.config)
+	if err != nil {
+		return proto.ExecutionStatus_FAILURE, err
+	}
+	logRetries(dp.logger)
+
+	if dp.serverLister == nil {
+		dp.serverLister = NewAzureServerLister(dp.logger, cred, dp.clientOptions, dp.subscriptionIDs(), dp.resourceGroups(), ServerKindsFromConfig(dp.config))
+	}
+	if dp.newPolicyEvaluator == nil {
+		dp.newPolicyEvaluator = NewPolicyProcessor
+	}
+
+	// A dry run builds none of the configured sinks, so it neither creates output_dir nor needs a blob container.
+	if ConfigBool(dp.config, "dry_run") {
+		dp.logger.Info("Dry run enabled, evidence will be logged instead of sent")
+		dp.sinks = []EvidenceSink{NewDryRunSink(dp.logger)}
+	} else {
+		sinks, err := NewEvidenceSinks(dp.config, dp.apiHelper, cred, dp.clientOptions, uuid.New().String())
+		if err != nil {
+			return proto.ExecutionStatus_FAILURE, err
+		}
+		dp.sinks = sinks
+	}
+
+	sanitizer, err := NewLabelSanitizer(dp.config)
+	if err != nil {
+		return proto.ExecutionStatus_FAILURE, err
+	}
+
+	templates, err := NewEvidenceTemplates(dp.config)
+	if err != nil {
+		return proto.ExecutionStatus_FAILURE, err
+	}
+
+	dp.maintenanceSchedule, err = ParseMaintenanceSchedule(dp.config)
+	if err != nil {
+		return proto.ExecutionStatus_FAILURE, err
+	}
+
+	tagSelector, err := ParseTagSelector(dp.config["tag_filter"])
+	if err != nil {
+		return proto.ExecutionStatus_FAILURE, fmt.Errorf("tag_filter: %w", err)
+	}
+
+	windowSelector, err := ParseTagSelector(dp.config["tag_window_filter"])
+	if err != nil {
+		return proto.ExecutionStatus_FAILURE, fmt.Errorf("tag_window_filter: %w", err)
+	}
+
+	controlMappings, err := ParseControlMappings(dp.config)
+	if err != nil {
+		return proto.ExecutionStatus_FAILURE, err
+	}
+
+	nameFilter, err := ParseNameFilter(dp.config["server_name_include"], dp.config["server_name_exclude"])
+	if err != nil {
+		return proto.ExecutionStatus_FAILURE, err
+	}
+
+	if source := dp.config["inline_policy"]; source != "" {
+		inlinePath, cleanup, err := PrepareInlinePolicy(source)
+		if err != nil {
+			dp.logger.Error("Error preparing inline policy", "error", err)
+			return proto.ExecutionStatus_FAILURE, err
+		}
+		defer cleanup()
+
+		if ConfigBool(dp.config, "inline_policy_only") {
+			policyPaths = []string{inlinePath}
+		} else {
+			policyPaths = append(append([]string{}, policyPaths...), inlinePath)
+		}
+	}
+
+	failOnViolation := ConfigBool(dp.config, "fail_on_policy_violation")
+
+	if len(policyPaths) == 0 {
+		dp.logger.Info("No policy paths provided, skipping policy evaluation. Only built-in checks and collected data will be emitted.")
+	}
 
 	activities := make([]*proto.Activity, 0)
 	activities = append(activities, &proto.Activity{
@@ -51,160 +219,406 @@ func (dp *AzureDataProcessor) Process(policyPaths []string) (proto.ExecutionStat
 		},
 	})
 
+	concurrency, err := ConfigInt(dp.config, "concurrency", defaultConcurrency)
+	if err != nil {
+		return proto.ExecutionStatus_FAILURE, err
+	}
+	if concurrency < 1 {
+		return proto.ExecutionStatus_FAILURE, fmt.Errorf("concurrency must be at least 1")
+	}
+
+	policyConcurrency, err := ConfigInt(dp.config, "policy_concurrency", defaultPolicyConcurrency)
+	if err != nil {
+		return proto.ExecutionStatus_FAILURE, err
+	}
+	if policyConcurrency < 1 {
+		return proto.ExecutionStatus_FAILURE, fmt.Errorf("policy_concurrency must be at least 1")
+	}
+
+	batchSize, err := ConfigInt(dp.config, "evidence_batch_size", defaultEvidenceBatchSize)
+	if err != nil {
+		return proto.ExecutionStatus_FAILURE, err
+	}
+	if batchSize < 1 {
+		return proto.ExecutionStatus_FAILURE, fmt.Errorf("evidence_batch_size must be at least 1")
+	}
+
+	tagLabels := ConfigList(dp.config, "tag_labels", []string{"*"})
+	for i := range tagLabels {
+		tagLabels[i] = strings.ToLower(tagLabels[i])
+	}
+
+	run := &serverRun{
+		policyPaths:       policyPaths,
+		policyConcurrency: policyConcurrency,
+		activities:        activities,
+		sanitizer:         sanitizer,
+		templates:         templates,
+		tagSelector:       tagSelector,
+		windowSelector:    windowSelector,
+		nameFilter:        nameFilter,
+		controlMappings:   controlMappings,
+		tagLabels:         tagLabels,
+		failOnViolation:   failOnViolation,
+		errs:              errs,
+		batchSize:         batchSize,
+		assessed:          map[string]int{},
+		metrics:           metrics,
+	}
+
+	// Servers are listed on this goroutine and assessed by a bounded pool of workers, so listing continues while
+	// earlier servers are evaluated. The channel is closed once listing ends, which lets every worker exit.
+	servers := make(chan *armpostgresqlflexibleservers.Server)
+	wg := sync.WaitGroup{}
+	for range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for server := range servers {
+				dp.processServer(server, run)
+			}
+		}()
+	}
+
+	// Listing time excludes the time spent waiting for a free worker.
+	listFailures := map[string]bool{}
+	listStart := time.Now()
 	for server, err := range dp.GetPostgresFlexibleServers() {
+		observe(&metrics.listing, listStart)
 		if err != nil {
 			dp.logger.Error("Error retrieving Azure PostgreSQL servers", "error", err)
-			evalStatus = proto.ExecutionStatus_FAILURE
-			accumulatedErrors = errors.Join(accumulatedErrors, err)
-			break
-		}
+			run.failed.Store(true)
 
-		idparts, err := ParseAzureResourceID(*server.ID)
-		if err != nil {
-			dp.logger.Error("Error parsing Azure resource ID", "error", err)
-			accumulatedErrors = errors.Join(accumulatedErrors, err)
+			scope := strings.Join(dp.subscriptionIDs(), ",")
+			var collectionErr *CollectionError
+			if errors.As(err, &collectionErr) {
+				scope = collectionErr.Scope()
+				listFailures[collectionErr.SubscriptionID] = true
+			}
+			errs.Add(scope, "list servers", "", err)
+
+			// Servers already processed keep their evidence. Only stop listing when the remaining subscriptions
+			// would fail in the same way.
+			if collectionErr != nil && collectionErr.Fatal {
+				break
+			}
+			listStart = time.Now()
 			continue
 		}
 
-		labels := map[string]string{
-			"provider":        "azure",
-			"type":            "database",
-			"instance-id":     *server.ID,
-			"resource-group":  idparts["resourceGroups"],
-			"location":        normaliseLocation(*server.Location),
-			"name":            *server.Name,
-			"subscription_id": idparts["subscriptions"],
-		}
+		metrics.listed.Add(1)
+		servers <- server
+		listStart = time.Now()
+	}
+	close(servers)
+	wg.Wait()
+	dp.flushEvidence(run)
 
-		actors := []*proto.OriginActor{
-			{
-				Title: "The Continuous Compliance Framework",
-				Type:  "assessment-platform",
-				Links: []*proto.Link{
-					{
-						Href: "https://compliance-framework.github.io/docs/",
-						Rel:  StringAddressed("reference"),
-						Text: StringAddressed("The Continuous Compliance Framework"),
-					},
-				},
-			},
-			{
-				Title: "Continuous Compliance Framework - Azure DB PSQL Plugin",
-				Type:  "tool",
-				Links: []*proto.Link{
-					{
-						Href: "https://github.com/compliance-framework/plugin-azure-db-psql",
-						Rel:  StringAddressed("reference"),
-						Text: StringAddressed("The Continuous Compliance Framework's Azure DB PSQL Plugin"),
-					},
-				},
-			},
-		}
+	if err := dp.ctx.Err(); err != nil {
+		dp.logger.Error("Run stopped before every server was assessed", "error", err)
+		errs.Add(strings.Join(dp.subscriptionIDs(), ","), "collect servers", ErrorCategoryTimeout, fmt.Errorf("run stopped early: %w", err))
+		run.failed.Store(true)
+	} else {
+		dp.emitHeartbeats(run, listFailures)
+	}
 
-		components := []*proto.Component{
-			{
-				Identifier:  "common-components/az-postgres-database",
-				Title:       "Azure PostgreSQL Database",
-				Description: "A PostgreSQL database hosted on Azure, managed by the Azure PostgreSQL Flexible Servers service.",
-				Purpose:     "To provide a managed PostgreSQL database service on Azure.",
-			},
-		}
+	if run.failed.Load() {
+		evalStatus = proto.ExecutionStatus_FAILURE
+	}
 
-		inventory := []*proto.InventoryItem{
-			{
-				Identifier: fmt.Sprintf("azure-postgres-database/%s", *server.ID),
-				Type:       "database",
-				Title:      *server.Name,
-				Props: []*proto.Property{
-					{
-						Name:  "vm-id",
-						Value: *server.ID,
-					},
-					{
-						Name:  "vm-name",
-						Value: *server.Name,
-					},
-				},
-			},
-		}
+	dp.reportErrors(errs, activities)
+	metrics.log(dp.logger)
+	dp.summary = NewRunSummary(dp.subscriptionIDs(), metrics, errs.Report())
 
-		subjects := []*proto.Subject{
-			{
-				Type:       proto.SubjectType_SUBJECT_TYPE_COMPONENT,
-				Identifier: "common-components/az-postgres-database",
-			},
-			{
-				Type:       proto.SubjectType_SUBJECT_TYPE_INVENTORY_ITEM,
-				Identifier: fmt.Sprintf("azure-postgres-database/%s", *server.ID),
-			},
-		}
+	return evalStatus, errs.Err()
+}
 
-		evidences := make([]*proto.Evidence, 0)
-		for _, policyPath := range policyPaths {
-			processor := policyManager.NewPolicyProcessor(
-				dp.logger,
-				labels,
-				subjects,
-				components,
-				inventory,
-				actors,
-				activities,
-			)
-
-			evidence, err := processor.GenerateResults(dp.ctx, policyPath, server)
-			evidences = append(evidences, evidence...)
+const (
+	// defaultConcurrency is the number of servers assessed at once unless concurrency is configured.
+	defaultConcurrency = 4
+	// defaultPolicyConcurrency is the number of policy paths evaluated at once for each server unless
+	// policy_concurrency is configured.
+	defaultPolicyConcurrency = 2
+)
 
-			if err != nil {
-				dp.logger.Error("Error processing policy", "policyPath", policyPath, "error", err)
-				accumulatedErrors = errors.Join(accumulatedErrors, err)
+// serverRun is the setup shared by every server assessed in a run. Workers only read it, apart from recording
+// errors and failure, which are safe for concurrent use.
+type serverRun struct {
+	policyPaths       []string
+	policyConcurrency int
+	activities        []*proto.Activity
+	sanitizer         *LabelSanitizer
+	templates         *EvidenceTemplates
+	tagSelector       *TagSelector
+	windowSelector    *TagSelector
+	nameFilter        *NameFilter
+	controlMappings   *ControlMappings
+	// tagLabels are the normalised tag keys copied to evidence labels, where * copies every tag.
+	tagLabels       []string
+	failOnViolation bool
+
+	errs   *ErrorAggregator
+	failed atomic.Bool
+
+	batchSize int
+	pendingMu sync.Mutex
+	// pending is the evidence waiting to be written as a batch.
+	pending []*proto.Evidence
+
+	assessedMu sync.Mutex
+	// assessed counts the servers assessed per lower cased subscription ID, after filtering.
+	assessed map[string]int
+
+	metrics *runMetrics
+}
+
+func (r *serverRun) recordAssessed(subscriptionID string) {
+	r.assessedMu.Lock()
+	defer r.assessedMu.Unlock()
+	r.assessed[strings.ToLower(subscriptionID)]++
+}
+
+// processServer collects, evaluates and writes the evidence for a single server. A panic is recovered and recorded
+// as an error, so one server can't take down the rest of the run.
+func (dp *AzureDataProcessor) processServer(server *armpostgresqlflexibleservers.Server, run *serverRun) {
+	defer func() {
+		if r := recover(); r != nil {
+			id := ""
+			if server.ID != nil {
+				id = *server.ID
 			}
+			dp.logger.Error("Panic while processing server", "server", id, "panic", r)
+			run.errs.Add(id, "process server", ErrorCategoryInternal, fmt.Errorf("panic: %v", r))
+			run.failed.Store(true)
 		}
+	}()
 
-		if err := dp.apiHelper.CreateEvidence(dp.ctx, evidences); err != nil {
-			dp.logger.Error("Error creating evidence", "error", err)
-			accumulatedErrors = errors.Join(accumulatedErrors, err)
-			evalStatus = proto.ExecutionStatus_FAILURE
-			continue
-		}
+	// Servers queued before the run's deadline are skipped rather than assessed on data that can't be collected.
+	if dp.ctx.Err() != nil {
+		dp.logger.Debug("Skipping server as the run has stopped", "server", *server.ID)
+		return
+	}
+
+	idparts, err := ParseAzureResourceID(*server.ID)
+	if err != nil {
+		dp.logger.Error("Error parsing Azure resource ID", "error", err)
+		run.errs.Add(*server.ID, "parse resource ID", ErrorCategoryInternal, err)
+		return
 	}
 
-	return evalStatus, accumulatedErrors
+	if !run.nameFilter.Matches(*server.Name) {
+		dp.logger.Debug("Skipping server not matching the configured name filter", "server", *server.Name)
+		run.metrics.skipped.Add(1)
+		return
+	}
+
+	if !run.tagSelector.Matches(server.Tags) {
+		dp.logger.Debug("Skipping server not matching the configured tag filter", "server", *server.Name)
+		run.metrics.skipped.Add(1)
+		return
+	}
+
+	if !run.windowSelector.Matches(server.Tags) {
+		dp.logger.Debug("Skipping server outside the configured tag window", "server", *server.Name)
+		run.metrics.skipped.Add(1)
+		return
+	}
+
+	run.recordAssessed(idparts.SubscriptionID())
+
+	// Servers that are deploying, updating or stopped only report part of their configuration, so rather than
+	// collecting and evaluating it the run records that the server exists in its current state.
+	if state, ready := serverState(server); !ready {
+		dp.logger.Info("Skipping collection for server that is not ready", "server", *server.ID, "state", state)
+		data := dp.newServerData(server)
+		ec := newServerEvidenceContext(data, idparts, dp.GetTenantID(idparts.SubscriptionID()), run.tagLabels, run.activities)
+		dp.queueServerEvidence(run, dp.checkServerState(ec, data, state))
+		return
+	}
+
+	collectStart := time.Now()
+	data := dp.collectServerData(server)
+	collectDuration := observe(&run.metrics.collection, collectStart)
+	run.metrics.collected.Add(1)
+	if err := dp.writeServerData(data); err != nil {
+		dp.logger.Error("Error writing collected server data", "error", err)
+		run.errs.Add(*server.ID, "write server data", "", err)
+	}
+
+	ec := newServerEvidenceContext(data, idparts, dp.GetTenantID(idparts.SubscriptionID()), run.tagLabels, run.activities)
+
+	evaluateStart := time.Now()
+	evidences := make([]*proto.Evidence, 0)
+	evidences = append(evidences, dp.runBuiltinChecks(ec, data)...)
+	policyEvidences := dp.evaluatePolicies(ec, data, run.policyPaths, run.policyConcurrency, run.errs)
+	evidences = append(evidences, policyEvidences...)
+	evaluateDuration := observe(&run.metrics.evaluation, evaluateStart)
+	run.metrics.evaluated.Add(1)
+
+	dp.logger.Debug("Server assessed", "server", *server.ID, "subscription", idparts.SubscriptionID(),
+		"collection_duration", collectDuration, "evaluation_duration", evaluateDuration, "evidence", len(evidences))
+
+	if run.failOnViolation && hasPolicyViolation(policyEvidences) {
+		dp.logger.Info("Policy violation found, the run will report failure", "server", *server.ID)
+		run.failed.Store(true)
+	}
+
+	dp.queueServerEvidence(run, evidences)
 }
 
-func (dp *AzureDataProcessor) GetPostgresFlexibleServers() iter.Seq2[*armpostgresqlflexibleservers.Server, error] {
-	return func(yield func(*armpostgresqlflexibleservers.Server, error) bool) {
-		cred, err := azidentity.NewDefaultAzureCredential(nil)
-		if err != nil {
-			dp.logger.Error("unable to get Azure credentials", "error", err)
-			yield(nil, err)
-			return
-		}
-		dp.logger.Debug("Azure credentials obtained successfully")
+// queueServerEvidence applies the run's control mappings, templates and label sanitizing to a server's evidence
+// before queueing it to be written.
+func (dp *AzureDataProcessor) queueServerEvidence(run *serverRun, evidences []*proto.Evidence) {
+	for _, evidence := range evidences {
+		run.controlMappings.Apply(dp.logger, evidence)
+		run.templates.Apply(evidence)
+		run.sanitizer.SanitizeLabels(dp.logger, evidence.Labels)
+	}
 
-		client, err := armpostgresqlflexibleservers.NewServersClient(dp.config["subscription_id"], cred, nil)
-		if err != nil {
-			dp.logger.Error("unable to create Azure PostgreSQL client", "error", err)
-			yield(nil, err)
-			return
-		}
+	dp.queueEvidence(run, evidences)
+}
+
+// evaluatePolicies evaluates the server data against each policy path, continuing past failing paths. Up to
+// policyConcurrency paths are evaluated at once, each with its own processor, and the evidence is returned in
+// policy path order.
+func (dp *AzureDataProcessor) evaluatePolicies(ec *EvidenceContext, data *ServerData, policyPaths []string, policyConcurrency int, errs *ErrorAggregator) []*proto.Evidence {
+	evidences := make([]*proto.Evidence, 0)
+	if len(policyPaths) == 0 {
+		return evidences
+	}
 
-		dp.logger.Debug("Azure PostgreSQL client created successfully", "client", client)
+	results := make([][]*proto.Evidence, len(policyPaths))
+	semaphore := make(chan struct{}, policyConcurrency)
+	wg := sync.WaitGroup{}
+	for i, policyPath := range policyPaths {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			defer func() {
+				if r := recover(); r != nil {
+					dp.logger.Error("Panic while processing policy", "policyPath", policyPath, "panic", r)
+					errs.Add(*data.ID, "evaluate policy "+policyPath, ErrorCategoryPolicy, fmt.Errorf("panic: %v", r))
+				}
+			}()
 
-		pager := client.NewListPager(nil)
+			processor := dp.newPolicyEvaluator(dp.logger, ec)
+			evidence, err := processor.GenerateResults(dp.ctx, policyPath, data)
+			results[i] = evidence
 
-		for pager.More() {
-			page, err := pager.NextPage(dp.ctx)
 			if err != nil {
-				dp.logger.Error("unable to list Azure PostgreSQL servers", "error", err)
-				yield(nil, err)
-				return
+				dp.logger.Error("Error processing policy", "policyPath", policyPath, "error", err)
+				errs.Add(*data.ID, "evaluate policy "+policyPath, ErrorCategoryPolicy, err)
 			}
+		}()
+	}
+	wg.Wait()
 
-			for _, server := range page.Value {
-				if !yield(server, nil) {
-					return
-				}
-			}
+	for _, result := range results {
+		evidences = append(evidences, result...)
+	}
+	return evidences
+}
+
+// hasPolicyViolation reports whether any of the policy evidence is not satisfied.
+func hasPolicyViolation(evidences []*proto.Evidence) bool {
+	for _, evidence := range evidences {
+		if evidence.Status != nil && evidence.Status.State == proto.EvidenceStatusState_EVIDENCE_STATUS_STATE_NOT_SATISFIED {
+			return true
 		}
 	}
+	return false
+}
+
+// writeEvidence sends the evidence to every configured sink, attempting all of them even if one fails.
+func (dp *AzureDataProcessor) writeEvidence(evidences []*proto.Evidence) error {
+	ctx, cancel := dp.writeContext()
+	defer cancel()
+
+	batch := dp.newEvidenceBatch(evidences)
+	var err error
+	for _, sink := range dp.sinks {
+		err = errors.Join(err, sink.WriteEvidence(ctx, batch))
+	}
+	return err
+}
+
+func (dp *AzureDataProcessor) writeServerData(data *ServerData) error {
+	ctx, cancel := dp.writeContext()
+	defer cancel()
+
+	var err error
+	for _, sink := range dp.sinks {
+		err = errors.Join(err, sink.WriteServerData(ctx, data))
+	}
+	return err
+}
+
+// flushTimeout bounds writes made after the run's context has ended.
+const flushTimeout = 30 * time.Second
+
+// writeContext returns the context for writing to the sinks. Once the run's context has ended, writes get a short
+// grace period of their own so that evidence already produced is still flushed.
+func (dp *AzureDataProcessor) writeContext() (context.Context, context.CancelFunc) {
+	if dp.ctx.Err() == nil {
+		return dp.ctx, func() {}
+	}
+	return context.WithTimeout(context.WithoutCancel(dp.ctx), flushTimeout)
+}
+
+// reportErrors logs the structured error report for the run, and uploads it as diagnostic evidence when enabled.
+func (dp *AzureDataProcessor) reportErrors(errs *ErrorAggregator, activities []*proto.Activity) {
+	report := errs.Report()
+	if len(report) == 0 {
+		return
+	}
+
+	reportJSON, err := json.Marshal(report)
+	if err != nil {
+		dp.logger.Error("Error encoding error report", "error", err)
+		return
+	}
+	dp.logger.Error("Run completed with errors", "count", len(report), "report", string(reportJSON))
+
+	if !ConfigBool(dp.config, "upload_error_report") {
+		return
+	}
+
+	ec := newRunEvidenceContext(strings.Join(dp.subscriptionIDs(), ","), activities)
+	evidence, err := ec.NewEvidence(
+		"builtin_error_report",
+		fmt.Sprintf("Azure PostgreSQL collection completed with %d error(s).", len(report)),
+		string(reportJSON),
+		&proto.EvidenceStatus{
+			Reason:  "fail",
+			Remarks: fmt.Sprintf("%d error(s) occurred while collecting and evaluating Azure PostgreSQL servers.", len(report)),
+			State:   proto.EvidenceStatusState_EVIDENCE_STATUS_STATE_NOT_SATISFIED,
+		},
+	)
+	if err != nil {
+		dp.logger.Error("Error creating error report evidence", "error", err)
+		return
+	}
+	if err := dp.writeEvidence([]*proto.Evidence{evidence}); err != nil {
+		dp.logger.Error("Error uploading error report evidence", "error", err)
+	}
+}
+
+func (dp *AzureDataProcessor) getARMClient() (*ARMClient, error) {
+	dp.armClientMu.Lock()
+	defer dp.armClientMu.Unlock()
+
+	if dp.armClient != nil {
+		return dp.armClient, nil
+	}
+
+	client, err := NewARMClient(dp.credential, dp.clientOptions)
+	if err != nil {
+		dp.logger.Error("unable to create Azure Resource Manager client", "error", err)
+		return nil, err
+	}
+
+	dp.armClient = client
+	return client, nil
 }
@@ -0,0 +1,251 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"iter"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/postgresql/armpostgresqlflexibleservers"
+	"github.com/compliance-framework/agent/runner/proto"
+	"github.com/hashicorp/go-hclog"
+)
+
+// listedServer is a server, or the error in its place, yielded by a fakeLister.
+type listedServer struct {
+	server *armpostgresqlflexibleservers.Server
+	err    error
+}
+
+// fakeLister yields a fixed set of servers and errors.
+type fakeLister []listedServer
+
+func (l fakeLister) ListServers(context.Context) iter.Seq2[*armpostgresqlflexibleservers.Server, error] {
+	return func(yield func(*armpostgresqlflexibleservers.Server, error) bool) {
+		for _, listed := range l {
+			if !yield(listed.server, listed.err) {
+				return
+			}
+		}
+	}
+}
+
+// fakeAPIHelper records the evidence sent to the compliance API.
+type fakeAPIHelper struct {
+	mu        sync.Mutex
+	evidences []*proto.Evidence
+}
+
+func (h *fakeAPIHelper) CreateEvidence(_ context.Context, evidences []*proto.Evidence) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.evidences = append(h.evidences, evidences...)
+	return nil
+}
+
+// policies returns the sorted _policy label of each evidence sent.
+func (h *fakeAPIHelper) policies() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	policies := make([]string, 0, len(h.evidences))
+	for _, evidence := range h.evidences {
+		policies = append(policies, evidence.Labels["_policy"])
+	}
+	sort.Strings(policies)
+	return policies
+}
+
+// stoppedServer returns a server that isn't ready, so a run records its state without collecting from Azure.
+func stoppedServer(id string, name string) listedServer {
+	return listedServer{server: &armpostgresqlflexibleservers.Server{
+		ID:       to.Ptr(id),
+		Name:     to.Ptr(name),
+		Type:     to.Ptr("Microsoft.DBforPostgreSQL/flexibleServers"),
+		Location: to.Ptr("uksouth"),
+		Properties: &armpostgresqlflexibleservers.ServerProperties{
+			State: to.Ptr(armpostgresqlflexibleservers.ServerStateStopped),
+		},
+	}}
+}
+
+// newTestProcessor returns a processor for the subscriptions that lists servers from lister and sends evidence to
+// the returned API helper. Tenant IDs are known up front so no Azure calls are made.
+func newTestProcessor(t *testing.T, subscriptionIDs string, lister PostgresServerLister) (*AzureDataProcessor, *fakeAPIHelper) {
+	t.Helper()
+
+	apiHelper := &fakeAPIHelper{}
+	credentials := NewCredentialCacheWithFactory(func(map[string]string) (azcore.TokenCredential, error) {
+		return fakeCredential{}, nil
+	})
+	dp := NewAzureDataProcessor(context.Background(), hclog.NewNullLogger(), map[string]string{
+		"subscription_ids": subscriptionIDs,
+	}, apiHelper, credentials)
+	dp.SetServerLister(lister)
+	for _, subscriptionID := range dp.subscriptionIDs() {
+		dp.tenantIDs[subscriptionID] = "tenant"
+	}
+	return dp, apiHelper
+}
+
+func TestProcessContinuesPastListingFailure(t *testing.T) {
+	listErr := NewCollectionError("sub-b", "list servers", errors.New("forbidden"))
+	dp, apiHelper := newTestProcessor(t, "sub-a,sub-b,sub-c", fakeLister{
+		stoppedServer("/subscriptions/sub-a/resourceGroups/rg/providers/Microsoft.DBforPostgreSQL/flexibleServers/a", "a"),
+		{err: listErr},
+		stoppedServer("/subscriptions/sub-c/resourceGroups/rg/providers/Microsoft.DBforPostgreSQL/flexibleServers/c", "c"),
+	})
+
+	status, err := dp.Process(nil)
+	if status != proto.ExecutionStatus_FAILURE {
+		t.Errorf("status = %v, want FAILURE", status)
+	}
+	if !errors.Is(err, listErr) {
+		t.Errorf("error = %v, want the listing error", err)
+	}
+
+	// The failed subscription gets neither server evidence nor a heartbeat, while the others are still assessed.
+	want := []string{"builtin_server_state", "builtin_server_state"}
+	if got := apiHelper.policies(); !slices.Equal(got, want) {
+		t.Errorf("evidence = %v, want %v", got, want)
+	}
+
+	if got := dp.summary.Errors; len(got) != 1 || got["sub-b"] == nil {
+		t.Errorf("summary errors = %v, want errors for sub-b only", got)
+	}
+}
+
+func TestProcessEmitsHeartbeatWithoutServers(t *testing.T) {
+	dp, apiHelper := newTestProcessor(t, "sub-a", fakeLister{})
+
+	status, err := dp.Process(nil)
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	if status != proto.ExecutionStatus_SUCCESS {
+		t.Errorf("status = %v, want SUCCESS", status)
+	}
+
+	want := []string{"builtin_heartbeat"}
+	if got := apiHelper.policies(); !slices.Equal(got, want) {
+		t.Errorf("evidence = %v, want %v", got, want)
+	}
+}
+
+func TestProcessSkipsUnparseableServer(t *testing.T) {
+	invalidID := "/subscriptions/sub-a/providers/Microsoft.DBforPostgreSQL/flexibleServers/broken"
+	dp, apiHelper := newTestProcessor(t, "sub-a", fakeLister{
+		stoppedServer(invalidID, "broken"),
+		stoppedServer("/subscriptions/sub-a/resourceGroups/rg/providers/Microsoft.DBforPostgreSQL/flexibleServers/a", "a"),
+	})
+
+	_, err := dp.Process(nil)
+	var collectionErr *CollectionError
+	if !errors.As(err, &collectionErr) || collectionErr.Operation != "parse resource ID" {
+		t.Fatalf("error = %v, want a parse resource ID error", err)
+	}
+
+	want := []string{"builtin_server_state"}
+	if got := apiHelper.policies(); !slices.Equal(got, want) {
+		t.Errorf("evidence = %v, want %v", got, want)
+	}
+	if got := dp.summary.ServersSkipped; got != 0 {
+		t.Errorf("servers skipped = %d, want 0", got)
+	}
+}
+
+// fakeEvaluator returns one evidence for each policy path it evaluates.
+type fakeEvaluator struct{}
+
+func (fakeEvaluator) GenerateResults(_ context.Context, policyPath string, _ interface{}) ([]*proto.Evidence, error) {
+	return []*proto.Evidence{{Title: policyPath}}, nil
+}
+
+func TestEvaluatePoliciesWithoutPolicyPaths(t *testing.T) {
+	dp, _ := newTestProcessor(t, "sub-a", fakeLister{})
+	var calls atomic.Int32
+	dp.SetPolicyEvaluatorFactory(func(hclog.Logger, *EvidenceContext) PolicyEvaluator {
+		calls.Add(1)
+		return fakeEvaluator{}
+	})
+
+	server := stoppedServer("/subscriptions/sub-a/resourceGroups/rg/providers/Microsoft.DBforPostgreSQL/flexibleServers/a", "a").server
+	idparts, err := ParseAzureResourceID(*server.ID)
+	if err != nil {
+		t.Fatalf("ParseAzureResourceID: %v", err)
+	}
+	data := dp.newServerData(server)
+	ec := newServerEvidenceContext(data, idparts, "tenant", nil, nil)
+
+	for name, policyPaths := range map[string][]string{"nil": nil, "empty": {}} {
+		if evidences := dp.evaluatePolicies(ec, data, policyPaths, 1, NewErrorAggregator()); len(evidences) != 0 {
+			t.Errorf("%s policy paths gave %d evidence, want none", name, len(evidences))
+		}
+	}
+	if got := calls.Load(); got != 0 {
+		t.Errorf("policy evaluator built %d times without policy paths, want 0", got)
+	}
+
+	if evidences := dp.evaluatePolicies(ec, data, []string{"policies"}, 1, NewErrorAggregator()); len(evidences) != 1 {
+		t.Errorf("one policy path gave %d evidence, want 1", len(evidences))
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("policy evaluator built %d times for one policy path, want 1", got)
+	}
+}
+
+func TestProcessWithoutPolicyPathsEmitsBuiltinEvidence(t *testing.T) {
+	dp, apiHelper := newTestProcessor(t, "sub-a,sub-b", fakeLister{
+		stoppedServer("/subscriptions/sub-a/resourceGroups/rg/providers/Microsoft.DBforPostgreSQL/flexibleServers/a", "a"),
+	})
+	var calls atomic.Int32
+	dp.SetPolicyEvaluatorFactory(func(hclog.Logger, *EvidenceContext) PolicyEvaluator {
+		calls.Add(1)
+		return fakeEvaluator{}
+	})
+
+	if _, err := dp.Process([]string{}); err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+
+	want := []string{"builtin_heartbeat", "builtin_server_state"}
+	if got := apiHelper.policies(); !slices.Equal(got, want) {
+		t.Errorf("evidence = %v, want %v", got, want)
+	}
+	if got := calls.Load(); got != 0 {
+		t.Errorf("policy evaluator built %d times without policy paths, want 0", got)
+	}
+}
+
+func TestProcessDryRunBuildsNoSinks(t *testing.T) {
+	dp, apiHelper := newTestProcessor(t, "sub-a", fakeLister{
+		stoppedServer("/subscriptions/sub-a/resourceGroups/rg/providers/Microsoft.DBforPostgreSQL/flexibleServers/a", "a"),
+	})
+	outputDir := filepath.Join(t.TempDir(), "evidence")
+	dp.config["dry_run"] = "true"
+	dp.config["sink"] = "api,blob,file"
+	dp.config["output_dir"] = outputDir
+
+	status, err := dp.Process(nil)
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	if status != proto.ExecutionStatus_SUCCESS {
+		t.Errorf("status = %v, want SUCCESS", status)
+	}
+
+	if got := apiHelper.policies(); len(got) != 0 {
+		t.Errorf("evidence sent during a dry run: %v", got)
+	}
+	if _, err := os.Stat(outputDir); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("output_dir created during a dry run: %v", err)
+	}
+}
@@ -0,0 +1,56 @@
+package internal
+
+import (
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/postgresql/armpostgresqlflexibleservers"
+)
+
+// Database is a database hosted on a server.
+type Database struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Charset   string `json:"charset,omitempty"`
+	Collation string `json:"collation,omitempty"`
+}
+
+// GetDatabases lists the databases of a server, including the built-in azure_maintenance, azure_sys and postgres
+// databases, so policies can flag unexpected ones.
+func (dp *AzureDataProcessor) GetDatabases(serverID string) ([]Database, error) {
+	idparts, err := ParseAzureResourceID(serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := armpostgresqlflexibleservers.NewDatabasesClient(idparts.SubscriptionID(), dp.credential, dp.clientOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	databases := make([]Database, 0)
+	pager := client.NewListByServerPager(idparts.ResourceGroup(), idparts.Name, nil)
+	for pager.More() {
+		page, err := pager.NextPage(dp.ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, database := range page.Value {
+			d := Database{}
+			if database.ID != nil {
+				d.ID = *database.ID
+			}
+			if database.Name != nil {
+				d.Name = *database.Name
+			}
+			if database.Properties != nil {
+				if database.Properties.Charset != nil {
+					d.Charset = *database.Properties.Charset
+				}
+				if database.Properties.Collation != nil {
+					d.Collation = *database.Properties.Collation
+				}
+			}
+			databases = append(databases, d)
+		}
+	}
+	return databases, nil
+}
@@ -0,0 +1,222 @@
+package internal
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	DBAuthMethodPassword = "password"
+	DBAuthMethodEntra    = "entra"
+
+	defaultDBName           = "postgres"
+	defaultDBSSLMode        = "verify-full"
+	defaultDBTimeoutSeconds = 30
+	defaultDBProbeMaxRows   = 1000
+	dbApplicationName       = "compliance-framework-plugin-azure-db-psql"
+	postgresPort            = 5432
+)
+
+// dbTokenScopes are the Entra ID scopes PostgreSQL servers accept access tokens for, by cloud.
+var dbTokenScopes = map[string]string{
+	CloudPublic: "https://ossrdbms-aad.database.windows.net/.default",
+	CloudUSGov:  "https://ossrdbms-aad.database.usgovcloudapi.net/.default",
+	CloudChina:  "https://ossrdbms-aad.database.chinacloudapi.cn/.default",
+}
+
+// SQLProbeResult is the outcome of a read-only SQL probe, keyed by probe name in the policy input.
+type SQLProbeResult struct {
+	// Rows are the rows the probe returned, keyed by column name, up to db_probe_max_rows.
+	Rows      []map[string]any `json:"rows"`
+	Truncated bool             `json:"truncated"`
+	// Error is set when the probe failed, in which case Rows is empty.
+	Error string `json:"error,omitempty"`
+}
+
+// ParseSQLProbes parses the db_probes JSON object of SQL queries keyed by probe name. Unset means no probes.
+func ParseSQLProbes(config map[string]string) (map[string]string, error) {
+	probes := map[string]string{}
+	if raw := config["db_probes"]; raw != "" {
+		if err := json.Unmarshal([]byte(raw), &probes); err != nil {
+			return nil, fmt.Errorf("db_probes must be a JSON object of SQL queries keyed by probe name: %w", err)
+		}
+	}
+	for name, query := range probes {
+		if strings.TrimSpace(query) == "" {
+			return nil, fmt.Errorf("db_probes: probe %q has no query", name)
+		}
+	}
+	return probes, nil
+}
+
+// validateDBConfig checks the database connection settings when db_connect is enabled.
+func validateDBConfig(config map[string]string) []error {
+	if !ConfigBool(config, "db_connect") {
+		return nil
+	}
+
+	var errs []error
+	if err := requireConfig(config, "db_user"); err != nil {
+		errs = append(errs, fmt.Errorf("db_connect: %w", err))
+	}
+	switch method := ConfigString(config, "db_auth_method", DBAuthMethodPassword); method {
+	case DBAuthMethodPassword:
+		if err := requireConfig(config, "db_password"); err != nil {
+			errs = append(errs, fmt.Errorf("db_auth_method %s: %w", method, err))
+		}
+	case DBAuthMethodEntra:
+	default:
+		errs = append(errs, fmt.Errorf("unsupported db_auth_method %q, expected %s or %s", method, DBAuthMethodPassword, DBAuthMethodEntra))
+	}
+	switch mode := ConfigString(config, "db_sslmode", defaultDBSSLMode); mode {
+	case "require", "verify-ca", "verify-full":
+	default:
+		errs = append(errs, fmt.Errorf("unsupported db_sslmode %q, expected require, verify-ca or verify-full", mode))
+	}
+	if _, err := ParseSQLProbes(config); err != nil {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// connectDatabase opens a read-only session to the server's database. Every transaction in the session defaults to
+// read only and statements are bounded by db_timeout_seconds, so a probe can neither change nor stall the server.
+func (dp *AzureDataProcessor) connectDatabase(host string) (*pgx.Conn, error) {
+	// The values were validated with the rest of the configuration.
+	timeoutSeconds, _ := ConfigInt(dp.config, "db_timeout_seconds", defaultDBTimeoutSeconds)
+	timeout := time.Duration(timeoutSeconds) * time.Second
+
+	// The host is parsed with the sslmode, so verify-full checks the certificate against the server's name.
+	connConfig, err := pgx.ParseConfig(fmt.Sprintf("host=%s port=%d sslmode=%s", host, postgresPort, ConfigString(dp.config, "db_sslmode", defaultDBSSLMode)))
+	if err != nil {
+		return nil, err
+	}
+	connConfig.Database = ConfigString(dp.config, "db_name", defaultDBName)
+	connConfig.User = ConfigString(dp.config, "db_user", "")
+	connConfig.ConnectTimeout = timeout
+	connConfig.RuntimeParams["application_name"] = dbApplicationName
+	connConfig.RuntimeParams["default_transaction_read_only"] = "on"
+	connConfig.RuntimeParams["statement_timeout"] = strconv.Itoa(int(timeout.Milliseconds()))
+
+	if ConfigString(dp.config, "db_auth_method", DBAuthMethodPassword) == DBAuthMethodEntra {
+		scope := dbTokenScopes[strings.ToLower(ConfigString(dp.config, "cloud", CloudPublic))]
+		token, err := dp.credential.GetToken(dp.ctx, policy.TokenRequestOptions{Scopes: []string{scope}})
+		if err != nil {
+			return nil, fmt.Errorf("unable to get an Entra ID token for the database: %w", err)
+		}
+		connConfig.Password = token.Token
+	} else {
+		connConfig.Password = dp.config["db_password"]
+	}
+
+	ctx, cancel := context.WithTimeout(dp.ctx, timeout)
+	defer cancel()
+	return pgx.ConnectConfig(ctx, connConfig)
+}
+
+// RunSQLProbes runs each probe in its own read-only transaction, so a failing probe doesn't affect the others.
+// Probes run in name order.
+func (dp *AzureDataProcessor) RunSQLProbes(conn *pgx.Conn, probes map[string]string) map[string]SQLProbeResult {
+	// The value was validated with the rest of the configuration.
+	maxRows, _ := ConfigInt(dp.config, "db_probe_max_rows", defaultDBProbeMaxRows)
+
+	results := map[string]SQLProbeResult{}
+	for _, name := range slices.Sorted(maps.Keys(probes)) {
+		rows, truncated, err := dp.queryReadOnly(conn, probes[name], maxRows)
+		result := SQLProbeResult{Rows: rows, Truncated: truncated}
+		if err != nil {
+			result = SQLProbeResult{Rows: make([]map[string]any, 0), Error: err.Error()}
+		}
+		results[name] = result
+	}
+	return results
+}
+
+// queryReadOnly runs a query in a read-only transaction, returning up to maxRows rows keyed by column name. The
+// transaction is always rolled back.
+func (dp *AzureDataProcessor) queryReadOnly(conn *pgx.Conn, query string, maxRows int) ([]map[string]any, bool, error) {
+	tx, err := conn.BeginTx(dp.ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, false, err
+	}
+	defer func() {
+		_ = tx.Rollback(dp.ctx)
+	}()
+
+	rows, err := tx.Query(dp.ctx, query)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	results := make([]map[string]any, 0)
+	truncated := false
+	fields := rows.FieldDescriptions()
+	for rows.Next() {
+		if len(results) == maxRows {
+			truncated = true
+			break
+		}
+		values, err := rows.Values()
+		if err != nil {
+			return nil, false, err
+		}
+		row := make(map[string]any, len(fields))
+		for i, field := range fields {
+			row[field.Name] = probeValue(values[i])
+		}
+		results = append(results, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, err
+	}
+	return results, truncated, nil
+}
+
+// probeValue converts a value pgx decoded into one that marshals to readable JSON for the policy input. UUIDs are
+// decoded as byte arrays and bytea as byte slices, pgtype values are converted to their driver values, and any other
+// types without a JSON or text form are formatted as strings.
+func probeValue(value any) any {
+	switch v := value.(type) {
+	case nil, bool, string, int16, int32, int64, float32, float64, time.Time:
+		return v
+	case []any:
+		values := make([]any, len(v))
+		for i := range v {
+			values[i] = probeValue(v[i])
+		}
+		return values
+	case map[string]any:
+		values := make(map[string]any, len(v))
+		for key, element := range v {
+			values[key] = probeValue(element)
+		}
+		return values
+	case [16]byte:
+		return uuid.UUID(v).String()
+	case []byte:
+		return string(v)
+	case json.Marshaler, encoding.TextMarshaler:
+		return v
+	case driver.Valuer:
+		// pgtype values such as intervals convert to their text form.
+		if converted, err := v.Value(); err == nil {
+			if _, ok := converted.(driver.Valuer); !ok {
+				return probeValue(converted)
+			}
+		}
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,102 @@
+package internal
+
+import (
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// databaseRolesQuery lists every role with the roles it is a member of. It only reads the catalog.
+const databaseRolesQuery = `SELECT r.rolname, r.rolsuper, r.rolcreaterole, r.rolcreatedb, r.rolcanlogin, r.rolreplication,
+	r.rolbypassrls, r.rolconnlimit, r.rolvaliduntil,
+	ARRAY(SELECT b.rolname FROM pg_catalog.pg_auth_members m JOIN pg_catalog.pg_roles b ON m.roleid = b.oid
+		WHERE m.member = r.oid ORDER BY b.rolname) AS memberof
+FROM pg_catalog.pg_roles r
+ORDER BY r.rolname`
+
+// builtinRoleNames are the roles Azure creates on every flexible server, alongside PostgreSQL's pg_ roles.
+var builtinRoleNames = []string{"azure_pg_admin", "azure_superuser", "azuresu", "replication"}
+
+// DatabaseRole is a role in the server's pg_roles catalog.
+type DatabaseRole struct {
+	Name        string `json:"name"`
+	Superuser   bool   `json:"superuser"`
+	CreateRole  bool   `json:"create_role"`
+	CreateDB    bool   `json:"create_db"`
+	CanLogin    bool   `json:"can_login"`
+	Replication bool   `json:"replication"`
+	BypassRLS   bool   `json:"bypass_rls"`
+	// ConnectionLimit is the most concurrent connections the role may make, where -1 means no limit.
+	ConnectionLimit int `json:"connection_limit"`
+	// ValidUntil is when the role's password expires. It is omitted for a password that never expires, in which case
+	// PasswordNeverExpires is true.
+	ValidUntil           *time.Time `json:"valid_until,omitempty"`
+	PasswordNeverExpires bool       `json:"password_never_expires"`
+	MemberOf             []string   `json:"member_of"`
+	// Builtin is true for PostgreSQL's pg_ roles and the roles Azure creates on every server.
+	Builtin bool `json:"builtin"`
+}
+
+// GetDatabaseRoles lists the server's roles from pg_roles, in a read-only transaction.
+func (dp *AzureDataProcessor) GetDatabaseRoles(conn *pgx.Conn) ([]DatabaseRole, error) {
+	tx, err := conn.BeginTx(dp.ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback(dp.ctx)
+	}()
+
+	rows, err := tx.Query(dp.ctx, databaseRolesQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	roles := make([]DatabaseRole, 0)
+	for rows.Next() {
+		role := DatabaseRole{}
+		var validUntil pgtype.Timestamptz
+		if err := rows.Scan(&role.Name, &role.Superuser, &role.CreateRole, &role.CreateDB, &role.CanLogin, &role.Replication,
+			&role.BypassRLS, &role.ConnectionLimit, &validUntil, &role.MemberOf); err != nil {
+			return nil, err
+		}
+		// PostgreSQL reports a password that never expires as null or infinity.
+		if validUntil.Valid && validUntil.InfinityModifier == pgtype.Finite {
+			role.ValidUntil = &validUntil.Time
+		}
+		role.PasswordNeverExpires = role.ValidUntil == nil
+		if role.MemberOf == nil {
+			role.MemberOf = make([]string, 0)
+		}
+		role.Builtin = strings.HasPrefix(role.Name, "pg_") || containsString(builtinRoleNames, role.Name)
+		roles = append(roles, role)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+// HasSuperuserLogin reports whether any role other than the built-in ones is a superuser that can log in.
+func HasSuperuserLogin(roles []DatabaseRole) bool {
+	for _, role := range roles {
+		if !role.Builtin && role.CanLogin && role.Superuser {
+			return true
+		}
+	}
+	return false
+}
+
+// HasNonExpiringLogin reports whether any role other than the built-in ones can log in with a password that never
+// expires.
+func HasNonExpiringLogin(roles []DatabaseRole) bool {
+	for _, role := range roles {
+		if !role.Builtin && role.CanLogin && role.PasswordNeverExpires {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,120 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/compliance-framework/agent/runner/proto"
+)
+
+const securityAssessmentsAPIVersion = "2021-06-01"
+
+// DefenderAssessment is a Microsoft Defender for Cloud security assessment of a server.
+type DefenderAssessment struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name,omitempty"`
+	// Status is Healthy, Unhealthy or NotApplicable.
+	Status string `json:"status"`
+	// Cause and Description explain the status, e.g. why an assessment is not applicable.
+	Cause       string `json:"cause,omitempty"`
+	Description string `json:"description,omitempty"`
+	// Severity is High, Medium or Low.
+	Severity  string `json:"severity,omitempty"`
+	PortalURL string `json:"portal_url,omitempty"`
+}
+
+type armSecurityAssessment struct {
+	ID         *string `json:"id"`
+	Name       *string `json:"name"`
+	Properties *struct {
+		DisplayName *string `json:"displayName"`
+		Status      *struct {
+			Code        *string `json:"code"`
+			Cause       *string `json:"cause"`
+			Description *string `json:"description"`
+		} `json:"status"`
+		Metadata *struct {
+			Severity *string `json:"severity"`
+		} `json:"metadata"`
+		Links *struct {
+			AzurePortalURI *string `json:"azurePortalUri"`
+		} `json:"links"`
+	} `json:"properties"`
+}
+
+// GetDefenderAssessments lists the Defender for Cloud assessments scoped to a server. A server Defender hasn't
+// assessed returns an empty list.
+func (dp *AzureDataProcessor) GetDefenderAssessments(serverID string) ([]DefenderAssessment, error) {
+	client, err := dp.getARMClient()
+	if err != nil {
+		return nil, err
+	}
+
+	assessments := make([]DefenderAssessment, 0)
+	// The severity is part of the assessment's metadata, which is only returned when expanded.
+	path := fmt.Sprintf("%s/providers/Microsoft.Security/assessments?$expand=metadata", serverID)
+	for assessment, err := range ListARMResources[armSecurityAssessment](dp.ctx, client, path, securityAssessmentsAPIVersion) {
+		if err != nil {
+			return nil, err
+		}
+
+		a := DefenderAssessment{}
+		if assessment.ID != nil {
+			a.ID = *assessment.ID
+		}
+		if assessment.Name != nil {
+			a.Name = *assessment.Name
+		}
+		if properties := assessment.Properties; properties != nil {
+			if properties.DisplayName != nil {
+				a.DisplayName = *properties.DisplayName
+			}
+			if status := properties.Status; status != nil {
+				if status.Code != nil {
+					a.Status = *status.Code
+				}
+				if status.Cause != nil {
+					a.Cause = *status.Cause
+				}
+				if status.Description != nil {
+					a.Description = *status.Description
+				}
+			}
+			if properties.Metadata != nil && properties.Metadata.Severity != nil {
+				a.Severity = *properties.Metadata.Severity
+			}
+			if properties.Links != nil && properties.Links.AzurePortalURI != nil {
+				a.PortalURL = *properties.Links.AzurePortalURI
+			}
+		}
+		assessments = append(assessments, a)
+	}
+	return assessments, nil
+}
+
+// Unhealthy reports whether Defender found the server failing the assessment.
+func (a DefenderAssessment) Unhealthy() bool {
+	return strings.EqualFold(a.Status, "Unhealthy")
+}
+
+// defenderAssessmentLinks links evidence to the Defender for Cloud findings for the server, so the security team's
+// findings can be followed up from the policy results.
+func defenderAssessmentLinks(assessments []DefenderAssessment) []*proto.Link {
+	links := make([]*proto.Link, 0)
+	for _, assessment := range assessments {
+		if !assessment.Unhealthy() || assessment.PortalURL == "" {
+			continue
+		}
+		href := assessment.PortalURL
+		if !strings.Contains(href, "://") {
+			href = "https://" + href
+		}
+		links = append(links, &proto.Link{
+			Href: href,
+			Rel:  StringAddressed("related"),
+			Text: StringAddressed(fmt.Sprintf("Defender for Cloud: %s (%s severity)", assessment.DisplayName, assessment.Severity)),
+		})
+	}
+	return links
+}
@@ -0,0 +1,176 @@
+package internal
+
+import (
+	"fmt"
+)
+
+const diagnosticSettingsAPIVersion = "2021-05-01-preview"
+
+// DiagnosticSetting is a diagnostic setting forwarding a server's logs and metrics to one or more destinations.
+type DiagnosticSetting struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// The destinations are only set when the setting forwards to them.
+	WorkspaceID string `json:"workspace_id,omitempty"`
+	// Workspace is the resolved Log Analytics workspace, when the setting forwards to one that could be read.
+	Workspace                   *LogAnalyticsWorkspace `json:"workspace,omitempty"`
+	StorageAccountID            string                 `json:"storage_account_id,omitempty"`
+	EventHubAuthorizationRuleID string                 `json:"event_hub_authorization_rule_id,omitempty"`
+	EventHubName                string                 `json:"event_hub_name,omitempty"`
+	// LogAnalyticsDestinationType is Dedicated for resource specific tables, or empty for the AzureDiagnostics table.
+	LogAnalyticsDestinationType string `json:"log_analytics_destination_type,omitempty"`
+	// Logs lists the log categories, or category groups such as audit or allLogs, and whether each is enabled.
+	Logs []DiagnosticLog `json:"logs"`
+	// Metrics lists the metric categories, such as AllMetrics, and whether each is enabled.
+	Metrics []DiagnosticMetric `json:"metrics"`
+}
+
+type DiagnosticLog struct {
+	Category      string `json:"category,omitempty"`
+	CategoryGroup string `json:"category_group,omitempty"`
+	Enabled       bool   `json:"enabled"`
+}
+
+type DiagnosticMetric struct {
+	Category string `json:"category"`
+	Enabled  bool   `json:"enabled"`
+}
+
+type armDiagnosticSetting struct {
+	ID         *string `json:"id"`
+	Name       *string `json:"name"`
+	Properties *struct {
+		WorkspaceID                 *string `json:"workspaceId"`
+		StorageAccountID            *string `json:"storageAccountId"`
+		EventHubAuthorizationRuleID *string `json:"eventHubAuthorizationRuleId"`
+		EventHubName                *string `json:"eventHubName"`
+		LogAnalyticsDestinationType *string `json:"logAnalyticsDestinationType"`
+		Logs                        []struct {
+			Category      *string `json:"category"`
+			CategoryGroup *string `json:"categoryGroup"`
+			Enabled       *bool   `json:"enabled"`
+		} `json:"logs"`
+		Metrics []struct {
+			Category *string `json:"category"`
+			Enabled  *bool   `json:"enabled"`
+		} `json:"metrics"`
+	} `json:"properties"`
+}
+
+// GetDiagnosticSettings lists the diagnostic settings of a server. A server without diagnostic settings returns an
+// empty list.
+func (dp *AzureDataProcessor) GetDiagnosticSettings(serverID string) ([]DiagnosticSetting, error) {
+	client, err := dp.getARMClient()
+	if err != nil {
+		return nil, err
+	}
+
+	settings := make([]DiagnosticSetting, 0)
+	path := fmt.Sprintf("%s/providers/Microsoft.Insights/diagnosticSettings", serverID)
+	for setting, err := range ListARMResources[armDiagnosticSetting](dp.ctx, client, path, diagnosticSettingsAPIVersion) {
+		if err != nil {
+			return nil, err
+		}
+
+		diagnosticSetting := DiagnosticSetting{
+			Logs:    make([]DiagnosticLog, 0),
+			Metrics: make([]DiagnosticMetric, 0),
+		}
+		if setting.ID != nil {
+			diagnosticSetting.ID = *setting.ID
+		}
+		if setting.Name != nil {
+			diagnosticSetting.Name = *setting.Name
+		}
+		if properties := setting.Properties; properties != nil {
+			if properties.WorkspaceID != nil {
+				diagnosticSetting.WorkspaceID = *properties.WorkspaceID
+			}
+			if properties.StorageAccountID != nil {
+				diagnosticSetting.StorageAccountID = *properties.StorageAccountID
+			}
+			if properties.EventHubAuthorizationRuleID != nil {
+				diagnosticSetting.EventHubAuthorizationRuleID = *properties.EventHubAuthorizationRuleID
+			}
+			if properties.EventHubName != nil {
+				diagnosticSetting.EventHubName = *properties.EventHubName
+			}
+			if properties.LogAnalyticsDestinationType != nil {
+				diagnosticSetting.LogAnalyticsDestinationType = *properties.LogAnalyticsDestinationType
+			}
+			for _, log := range properties.Logs {
+				diagnosticLog := DiagnosticLog{}
+				if log.Category != nil {
+					diagnosticLog.Category = *log.Category
+				}
+				if log.CategoryGroup != nil {
+					diagnosticLog.CategoryGroup = *log.CategoryGroup
+				}
+				if log.Enabled != nil {
+					diagnosticLog.Enabled = *log.Enabled
+				}
+				diagnosticSetting.Logs = append(diagnosticSetting.Logs, diagnosticLog)
+			}
+			for _, metric := range properties.Metrics {
+				diagnosticMetric := DiagnosticMetric{}
+				if metric.Category != nil {
+					diagnosticMetric.Category = *metric.Category
+				}
+				if metric.Enabled != nil {
+					diagnosticMetric.Enabled = *metric.Enabled
+				}
+				diagnosticSetting.Metrics = append(diagnosticSetting.Metrics, diagnosticMetric)
+			}
+		}
+		settings = append(settings, diagnosticSetting)
+	}
+	return settings, nil
+}
+
+// ForwardsLogs reports whether the setting sends at least one enabled log category to a Log Analytics workspace or
+// storage account.
+func (s DiagnosticSetting) ForwardsLogs() bool {
+	if s.WorkspaceID == "" && s.StorageAccountID == "" {
+		return false
+	}
+	for _, log := range s.Logs {
+		if log.Enabled {
+			return true
+		}
+	}
+	return false
+}
+
+// ForwardsMetrics reports whether the setting sends at least one enabled metric category to a Log Analytics workspace
+// or storage account.
+func (s DiagnosticSetting) ForwardsMetrics() bool {
+	if s.WorkspaceID == "" && s.StorageAccountID == "" {
+		return false
+	}
+	for _, metric := range s.Metrics {
+		if metric.Enabled {
+			return true
+		}
+	}
+	return false
+}
+
+// HasMetricForwarding reports whether any of the settings forwards metrics to a workspace or storage account.
+func HasMetricForwarding(settings []DiagnosticSetting) bool {
+	for _, setting := range settings {
+		if setting.ForwardsMetrics() {
+			return true
+		}
+	}
+	return false
+}
+
+// HasLogForwarding reports whether any of the settings forwards logs to a workspace or storage account.
+func HasLogForwarding(settings []DiagnosticSetting) bool {
+	for _, setting := range settings {
+		if setting.ForwardsLogs() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+package internal
+
+import (
+	"strings"
+)
+
+const (
+	EncryptionTypeSystemManaged = "SystemManaged"
+	EncryptionTypeAzureKeyVault = "AzureKeyVault"
+)
+
+// DataEncryption is a server's data encryption configuration in the policy input.
+type DataEncryption struct {
+	// Type is SystemManaged for service managed keys or AzureKeyVault for a customer-managed key.
+	Type                          string `json:"type"`
+	PrimaryKeyURI                 string `json:"primary_key_uri,omitempty"`
+	PrimaryUserAssignedIdentityID string `json:"primary_user_assigned_identity_id,omitempty"`
+	// PrimaryKeyStatus is Valid or Invalid, as reported by Azure for customer-managed keys.
+	PrimaryKeyStatus string `json:"primary_key_status,omitempty"`
+	// PrimaryKey is the Key Vault metadata of the primary key, when it could be read.
+	PrimaryKey *KeyVaultKey `json:"primary_key,omitempty"`
+	// The geo-backup key encrypts the geo-redundant backup copy in the paired region, and is only set for servers
+	// with both geo-redundant backup and a customer-managed key.
+	GeoBackupKeyURI                 string       `json:"geo_backup_key_uri,omitempty"`
+	GeoBackupUserAssignedIdentityID string       `json:"geo_backup_user_assigned_identity_id,omitempty"`
+	GeoBackupKeyStatus              string       `json:"geo_backup_key_status,omitempty"`
+	GeoBackupKey                    *KeyVaultKey `json:"geo_backup_key,omitempty"`
+}
+
+// NewDataEncryption summarises the server's data encryption. It returns nil when the extended server is unavailable.
+func NewDataEncryption(extended *ExtendedServer) *DataEncryption {
+	if extended == nil || extended.Properties == nil {
+		return nil
+	}
+
+	encryption := &DataEncryption{
+		Type: EncryptionTypeSystemManaged,
+	}
+	source := extended.Properties.DataEncryption
+	if source == nil {
+		return encryption
+	}
+	if source.Type != nil {
+		encryption.Type = *source.Type
+	}
+	if source.PrimaryKeyURI != nil {
+		encryption.PrimaryKeyURI = *source.PrimaryKeyURI
+	}
+	if source.PrimaryUserAssignedIdentityID != nil {
+		encryption.PrimaryUserAssignedIdentityID = *source.PrimaryUserAssignedIdentityID
+	}
+	if source.PrimaryEncryptionKeyStatus != nil {
+		encryption.PrimaryKeyStatus = *source.PrimaryEncryptionKeyStatus
+	}
+	if source.GeoBackupKeyURI != nil {
+		encryption.GeoBackupKeyURI = *source.GeoBackupKeyURI
+	}
+	if source.GeoBackupUserAssignedIdentityID != nil {
+		encryption.GeoBackupUserAssignedIdentityID = *source.GeoBackupUserAssignedIdentityID
+	}
+	if source.GeoBackupEncryptionKeyStatus != nil {
+		encryption.GeoBackupKeyStatus = *source.GeoBackupEncryptionKeyStatus
+	}
+	return encryption
+}
+
+// CustomerManaged reports whether the server is encrypted with a customer-managed key.
+func (e *DataEncryption) CustomerManaged() bool {
+	return strings.EqualFold(e.Type, EncryptionTypeAzureKeyVault)
+}
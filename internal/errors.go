@@ -0,0 +1,184 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+)
+
+const (
+	ErrorCategoryAuthorization = "authorization"
+	ErrorCategoryNotFound      = "not-found"
+	ErrorCategoryThrottled     = "throttled"
+	ErrorCategoryAzure         = "azure"
+	ErrorCategoryTimeout       = "timeout"
+	ErrorCategoryPolicy        = "policy"
+	ErrorCategoryInternal      = "internal"
+)
+
+// ErrorRecord is a machine readable description of a single failure within a run.
+type ErrorRecord struct {
+	Scope     string `json:"scope"`
+	Operation string `json:"operation"`
+	Category  string `json:"category"`
+	Message   string `json:"message"`
+}
+
+// CollectionError wraps a failure with the operation and the subscription, resource group and server it concerns,
+// where known. Unwrap exposes the underlying error, so callers can still match Azure's *azcore.ResponseError.
+type CollectionError struct {
+	Operation      string
+	SubscriptionID string
+	ResourceGroup  string
+	ServerID       string
+	Err            error
+	// Fatal is set when the failure will affect every other subscription as well, so the run should stop.
+	Fatal bool
+}
+
+// NewCollectionError wraps an error, deriving the subscription, resource group and server from the scope, which is
+// a subscription ID or a resource ID.
+func NewCollectionError(scope string, operation string, err error) *CollectionError {
+	collectionErr := &CollectionError{
+		Operation: operation,
+		Err:       err,
+	}
+
+	parsed, parseErr := ParseResourceID(scope)
+	switch {
+	case parseErr == nil && parsed.SubscriptionID() != "":
+		collectionErr.SubscriptionID = parsed.SubscriptionID()
+		collectionErr.ResourceGroup = parsed.ResourceGroup()
+		if parsed.Provider != "" {
+			collectionErr.ServerID = scope
+		}
+	default:
+		collectionErr.SubscriptionID = scope
+	}
+	return collectionErr
+}
+
+func (e *CollectionError) Error() string {
+	message := e.Operation
+	if e.ServerID != "" {
+		message += " for " + e.ServerID
+	} else {
+		if e.SubscriptionID != "" {
+			message += " in subscription " + e.SubscriptionID
+		}
+		if e.ResourceGroup != "" {
+			message += " resource group " + e.ResourceGroup
+		}
+	}
+	return fmt.Sprintf("%s: %s", message, e.Err)
+}
+
+// Scope returns the ID of the server, resource group or subscription the error concerns.
+func (e *CollectionError) Scope() string {
+	switch {
+	case e.ServerID != "":
+		return e.ServerID
+	case e.ResourceGroup != "":
+		return fmt.Sprintf("/subscriptions/%s/resourceGroups/%s", e.SubscriptionID, e.ResourceGroup)
+	default:
+		return e.SubscriptionID
+	}
+}
+
+func (e *CollectionError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorAggregator accumulates the errors of a run, keeping both the joined error returned to the agent and a
+// structured record of each failure. It is safe for concurrent use.
+type ErrorAggregator struct {
+	mu      sync.Mutex
+	err     error
+	records []ErrorRecord
+}
+
+func NewErrorAggregator() *ErrorAggregator {
+	return &ErrorAggregator{
+		records: make([]ErrorRecord, 0),
+	}
+}
+
+// Add records an error against the scope (a subscription or resource ID) and operation that produced it.
+// The joined error wraps each error in a *CollectionError carrying that context, unless it already is one.
+func (a *ErrorAggregator) Add(scope string, operation string, category string, err error) {
+	if err == nil {
+		return
+	}
+	if category == "" {
+		category = CategorizeError(err)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	collectionErr, ok := err.(*CollectionError)
+	if !ok {
+		collectionErr = NewCollectionError(scope, operation, err)
+	}
+	a.err = errors.Join(a.err, collectionErr)
+	a.records = append(a.records, ErrorRecord{
+		Scope:     scope,
+		Operation: operation,
+		Category:  category,
+		Message:   err.Error(),
+	})
+}
+
+// Err returns all recorded errors joined together, or nil if none were recorded.
+func (a *ErrorAggregator) Err() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.err
+}
+
+// Report returns a copy of the structured error records.
+func (a *ErrorAggregator) Report() []ErrorRecord {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]ErrorRecord{}, a.records...)
+}
+
+// IsFatalError reports whether an error will fail every later Azure call in the run too, so collection should stop
+// rather than carry on. These are credential failures and cancellation of the run's context.
+func IsFatalError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var authFailedErr *azidentity.AuthenticationFailedError
+	var authRequiredErr *azidentity.AuthenticationRequiredError
+	return errors.As(err, &authFailedErr) || errors.As(err, &authRequiredErr)
+}
+
+// CategorizeError classifies an error by its cause, so that reports can be filtered without parsing messages.
+func CategorizeError(err error) string {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return ErrorCategoryTimeout
+	}
+
+	var responseErr *azcore.ResponseError
+	if errors.As(err, &responseErr) {
+		switch {
+		case responseErr.StatusCode == http.StatusUnauthorized || responseErr.StatusCode == http.StatusForbidden:
+			return ErrorCategoryAuthorization
+		case responseErr.StatusCode == http.StatusNotFound:
+			return ErrorCategoryNotFound
+		case responseErr.StatusCode == http.StatusTooManyRequests:
+			return ErrorCategoryThrottled
+		default:
+			return ErrorCategoryAzure
+		}
+	}
+
+	return ErrorCategoryInternal
+}
@@ -0,0 +1,386 @@
+package internal
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/compliance-framework/agent/runner/proto"
+	"github.com/compliance-framework/api/sdk"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const databaseComponentIdentifier = "common-components/az-postgres-database"
+
+// EvidenceContext is the labelling and OSCAL context shared by all evidence produced for a single server,
+// whether it comes from a policy evaluation or from a check built into the plugin.
+type EvidenceContext struct {
+	labels     map[string]string
+	actors     []*proto.OriginActor
+	components []*proto.Component
+	inventory  []*proto.InventoryItem
+	subjects   []*proto.Subject
+	activities []*proto.Activity
+	links      []*proto.Link
+}
+
+func newServerEvidenceContext(server *ServerData, idparts *ResourceID, tenantID string, tagLabelKeys []string, activities []*proto.Activity) *EvidenceContext {
+	labels := map[string]string{
+		"provider":        "azure",
+		"type":            "database",
+		"instance-id":     *server.ID,
+		"resource-group":  idparts.ResourceGroup(),
+		"location":        normaliseLocation(*server.Location),
+		"name":            *server.Name,
+		"subscription_id": idparts.SubscriptionID(),
+		"server-type":     server.ServerType,
+	}
+
+	for key, value := range TagLabels(server.NormalisedTags, tagLabelKeys) {
+		labels[key] = value
+	}
+
+	if tenantID != "" {
+		labels["tenant-id"] = tenantID
+	}
+
+	if server.SKU != nil && server.SKU.Name != nil {
+		labels["sku-name"] = *server.SKU.Name
+	}
+	if server.SKU != nil && server.SKU.Tier != nil {
+		labels["sku-tier"] = string(*server.SKU.Tier)
+	}
+	if server.Environment != "" {
+		labels["environment"] = server.Environment
+	}
+
+	if server.Properties != nil && server.Properties.AdministratorLogin != nil {
+		labels["admin-login"] = *server.Properties.AdministratorLogin
+	}
+
+	if server.Replication != nil && server.Replication.Role != "" {
+		labels["replication-role"] = server.Replication.Role
+	}
+	if server.Replication != nil && server.Replication.IsReplica {
+		labels["replica"] = "true"
+	}
+
+	if HasDeleteLock(server.Locks) {
+		labels["delete-lock"] = "true"
+	}
+
+	actors := pluginActors()
+	serverComponent := serverComponentIdentifier(*server.ID)
+	components := append(databaseComponents(), &proto.Component{
+		Identifier:  serverComponent,
+		Title:       fmt.Sprintf("Azure PostgreSQL Database %s", *server.Name),
+		Description: fmt.Sprintf("The Azure PostgreSQL %s server %s.", server.ServerType, *server.ID),
+		Purpose:     "To provide a managed PostgreSQL database service on Azure.",
+	})
+	inventoryItem := serverInventoryIdentifier(*server.ID)
+
+	props := serverProps(server)
+
+	// Each firewall rule is listed so an auditor can see exactly which rule a network exposure policy tripped on.
+	for _, rule := range server.FirewallRules {
+		props = append(props, &proto.Property{
+			Name:    "firewall-rule",
+			Value:   fmt.Sprintf("%s: %s", rule.Name, rule.IPRange()),
+			Remarks: firewallRuleRemarks(rule),
+		})
+	}
+
+	inventory := []*proto.InventoryItem{
+		{
+			Identifier: inventoryItem,
+			Type:       "database",
+			Title:      *server.Name,
+			Props:      props,
+		},
+	}
+
+	// Databases are child inventory items of the server, so evidence reflects the data stores that actually exist.
+	for _, database := range server.Databases {
+		inventory = append(inventory, &proto.InventoryItem{
+			Identifier: serverInventoryIdentifier(database.ID),
+			Type:       "database",
+			Title:      fmt.Sprintf("%s/%s", *server.Name, database.Name),
+			Props: []*proto.Property{
+				{Name: "server-id", Value: *server.ID},
+				{Name: "database-name", Value: database.Name},
+				{Name: "charset", Value: database.Charset},
+				{Name: "collation", Value: database.Collation},
+			},
+		})
+	}
+
+	// The shared component groups every server for cross-cutting reporting, while the server's own component and
+	// inventory item tell findings for different servers apart.
+	subjects := []*proto.Subject{
+		{
+			Type:       proto.SubjectType_SUBJECT_TYPE_COMPONENT,
+			Identifier: databaseComponentIdentifier,
+		},
+		{
+			Type:       proto.SubjectType_SUBJECT_TYPE_COMPONENT,
+			Identifier: serverComponent,
+		},
+		{
+			Type:       proto.SubjectType_SUBJECT_TYPE_INVENTORY_ITEM,
+			Identifier: inventoryItem,
+		},
+	}
+
+	return &EvidenceContext{
+		labels:     labels,
+		actors:     actors,
+		components: components,
+		inventory:  inventory,
+		subjects:   subjects,
+		activities: activities,
+		links:      defenderAssessmentLinks(server.DefenderAssessments),
+	}
+}
+
+// serverProps describes the server on its inventory item. Every prop is always present, with an empty value when
+// the server doesn't report it, so inventory reports have consistent columns.
+func serverProps(server *ServerData) []*proto.Property {
+	var version, skuName, skuTier, storageSizeGB, haMode, geoRedundantBackup, state string
+	var maintenanceWindow, maintenanceDay, maintenanceHour string
+	var backupRetentionDays, publicNetworkAccess string
+	var replicationRole, replicaCount, sourceServerID string
+	var haState, availabilityZone, standbyAvailabilityZone string
+	var storageAutoGrow, storageTier, storageType, storageIOPS, storageThroughput string
+	var lastChange string
+	var requireSecureTransport, sslMinProtocolVersion string
+
+	if server.SKU != nil {
+		if server.SKU.Name != nil {
+			skuName = *server.SKU.Name
+		}
+		if server.SKU.Tier != nil {
+			skuTier = string(*server.SKU.Tier)
+		}
+	}
+
+	if properties := server.Properties; properties != nil {
+		if properties.Version != nil {
+			version = string(*properties.Version)
+		}
+		if properties.Backup != nil && properties.Backup.GeoRedundantBackup != nil {
+			geoRedundantBackup = string(*properties.Backup.GeoRedundantBackup)
+		}
+		if properties.State != nil {
+			state = string(*properties.State)
+		}
+	}
+
+	if window := server.Maintenance; window != nil {
+		maintenanceWindow = window.Mode
+		maintenanceDay = window.Day
+		if window.StartHour != nil {
+			maintenanceHour = strconv.Itoa(int(*window.StartHour))
+		}
+	}
+
+	if storage := server.Storage; storage != nil {
+		if storage.SizeGB != nil {
+			storageSizeGB = strconv.Itoa(int(*storage.SizeGB))
+		}
+		storageAutoGrow = storage.AutoGrow
+		storageTier = storage.Tier
+		storageType = storage.Type
+		if storage.IOPS != nil {
+			storageIOPS = strconv.Itoa(int(*storage.IOPS))
+		}
+		if storage.ThroughputMBps != nil {
+			storageThroughput = strconv.Itoa(int(*storage.ThroughputMBps))
+		}
+	}
+
+	if ha := server.HighAvailability; ha != nil {
+		haMode = ha.Mode
+		haState = ha.State
+		availabilityZone = ha.AvailabilityZone
+		standbyAvailabilityZone = ha.StandbyAvailabilityZone
+	}
+
+	if server.Backup != nil && server.Backup.RetentionDays != nil {
+		backupRetentionDays = strconv.Itoa(int(*server.Backup.RetentionDays))
+	}
+
+	if replication := server.Replication; replication != nil {
+		replicationRole = replication.Role
+		sourceServerID = replication.SourceServerID
+		// The count is only known for primaries whose replicas could be listed.
+		if replication.ReplicaCount != nil {
+			replicaCount = strconv.Itoa(*replication.ReplicaCount)
+		}
+	}
+
+	if last := LastChange(server.ActivityLog); last != nil {
+		lastChange = last.UTC().Format(time.RFC3339)
+	}
+
+	if facts := server.Facts; facts != nil {
+		if facts.RequireSecureTransport != nil {
+			requireSecureTransport = strconv.FormatBool(*facts.RequireSecureTransport)
+		}
+		if facts.SSLMinProtocolVersion != nil {
+			sslMinProtocolVersion = *facts.SSLMinProtocolVersion
+		}
+	}
+
+	if server.Network != nil && server.Network.PublicNetworkAccess != networkUnknown {
+		publicNetworkAccess = server.Network.PublicNetworkAccess
+	}
+
+	return []*proto.Property{
+		{Name: "server-id", Value: *server.ID},
+		{Name: "server-name", Value: *server.Name},
+		{Name: "version", Value: version},
+		{Name: "sku-name", Value: skuName},
+		{Name: "sku-tier", Value: skuTier},
+		{Name: "storage-size-gb", Value: storageSizeGB},
+		{Name: "storage-auto-grow", Value: storageAutoGrow},
+		{Name: "storage-tier", Value: storageTier},
+		{Name: "storage-type", Value: storageType},
+		{Name: "storage-iops", Value: storageIOPS},
+		{Name: "storage-throughput-mbps", Value: storageThroughput},
+		{Name: "high-availability-mode", Value: haMode},
+		{Name: "high-availability-state", Value: haState},
+		{Name: "availability-zone", Value: availabilityZone},
+		{Name: "standby-availability-zone", Value: standbyAvailabilityZone},
+		{Name: "geo-redundant-backup", Value: geoRedundantBackup},
+		{Name: "backup-retention-days", Value: backupRetentionDays},
+		{Name: "state", Value: state},
+		{Name: "maintenance-window", Value: maintenanceWindow},
+		{Name: "maintenance-day", Value: maintenanceDay},
+		{Name: "maintenance-hour", Value: maintenanceHour},
+		{Name: "public-network-access", Value: publicNetworkAccess},
+		{Name: "require-secure-transport", Value: requireSecureTransport},
+		{Name: "ssl-min-protocol-version", Value: sslMinProtocolVersion},
+		{Name: "replication-role", Value: replicationRole},
+		{Name: "replica-count", Value: replicaCount},
+		{Name: "source-server-id", Value: sourceServerID},
+		{Name: "last-change", Value: lastChange},
+	}
+}
+
+func firewallRuleRemarks(rule FirewallRule) *string {
+	if rule.AllowAll {
+		return StringAddressed("allows connections from any IPv4 address")
+	}
+	if rule.AllowAzureServices {
+		return StringAddressed("allows connections from any Azure service")
+	}
+	return nil
+}
+
+// newRunEvidenceContext builds the context for evidence describing the run as a whole rather than a single server.
+func newRunEvidenceContext(subscriptionID string, activities []*proto.Activity) *EvidenceContext {
+	return &EvidenceContext{
+		labels: map[string]string{
+			"provider":        "azure",
+			"type":            "database",
+			"subscription_id": subscriptionID,
+		},
+		actors:     pluginActors(),
+		components: databaseComponents(),
+		inventory:  []*proto.InventoryItem{},
+		subjects: []*proto.Subject{
+			{
+				Type:       proto.SubjectType_SUBJECT_TYPE_COMPONENT,
+				Identifier: databaseComponentIdentifier,
+			},
+		},
+		activities: activities,
+	}
+}
+
+func pluginActors() []*proto.OriginActor {
+	return []*proto.OriginActor{
+		{
+			Title: "The Continuous Compliance Framework",
+			Type:  "assessment-platform",
+			Links: []*proto.Link{
+				{
+					Href: "https://compliance-framework.github.io/docs/",
+					Rel:  StringAddressed("reference"),
+					Text: StringAddressed("The Continuous Compliance Framework"),
+				},
+			},
+		},
+		{
+			Title: "Continuous Compliance Framework - Azure DB PSQL Plugin",
+			Type:  "tool",
+			Links: []*proto.Link{
+				{
+					Href: "https://github.com/compliance-framework/plugin-azure-db-psql",
+					Rel:  StringAddressed("reference"),
+					Text: StringAddressed("The Continuous Compliance Framework's Azure DB PSQL Plugin"),
+				},
+			},
+		},
+	}
+}
+
+// serverComponentIdentifier identifies the component for a single server. Resource IDs are lower cased, as Azure
+// is inconsistent about their casing between calls, so the same server keeps the same identifier across runs.
+func serverComponentIdentifier(serverID string) string {
+	return fmt.Sprintf("%s/%s", databaseComponentIdentifier, strings.ToLower(strings.Trim(serverID, "/")))
+}
+
+// serverInventoryIdentifier identifies the inventory item for a single server. Unlike the component identifier it
+// keeps the resource ID exactly as Azure reports it, so existing inventory items keep their identifiers.
+func serverInventoryIdentifier(serverID string) string {
+	return fmt.Sprintf("azure-postgres-database/%s", serverID)
+}
+
+func databaseComponents() []*proto.Component {
+	return []*proto.Component{
+		{
+			Identifier:  databaseComponentIdentifier,
+			Title:       "Azure PostgreSQL Database",
+			Description: "A PostgreSQL database hosted on Azure, managed by the Azure PostgreSQL Flexible Servers service.",
+			Purpose:     "To provide a managed PostgreSQL database service on Azure.",
+		},
+	}
+}
+
+// NewEvidence builds an evidence item for a check performed by the plugin itself rather than by a policy.
+// The evidence UUID is seeded from the check name and the server labels, so the same check on the same server
+// keeps its history across runs.
+func (ec *EvidenceContext) NewEvidence(check string, title string, description string, status *proto.EvidenceStatus) (*proto.Evidence, error) {
+	labels, err := MergeMapsStrict(map[string]string{
+		"_policy": check,
+	}, ec.labels)
+	if err != nil {
+		return nil, fmt.Errorf("unable to build evidence labels for %s: %w", check, err)
+	}
+
+	evidenceUUID, err := sdk.SeededUUID(MergeMaps(map[string]string{
+		"type":   "evidence",
+		"policy": check,
+	}, ec.labels))
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate evidence UUID for %s: %w", check, err)
+	}
+
+	return &proto.Evidence{
+		UUID:           evidenceUUID.String(),
+		Title:          title,
+		Description:    StringAddressed(description),
+		Labels:         labels,
+		Links:          ec.links,
+		Start:          timestamppb.New(time.Now()),
+		End:            timestamppb.New(time.Now()),
+		Origins:        []*proto.Origin{{Actors: ec.actors}},
+		Activities:     ec.activities,
+		InventoryItems: ec.inventory,
+		Components:     ec.components,
+		Subjects:       ec.subjects,
+		Status:         status,
+	}, nil
+}
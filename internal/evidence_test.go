@@ -0,0 +1,20 @@
+package internal
+
+import "testing"
+
+func TestServerIdentifiers(t *testing.T) {
+	const serverID = "/subscriptions/Sub-A/resourceGroups/RG-A/providers/Microsoft.DBforPostgreSQL/flexibleServers/Server-A"
+
+	// The inventory item identifier predates the per-server component and is kept exactly as Azure reports the ID.
+	if got, want := serverInventoryIdentifier(serverID), "azure-postgres-database/"+serverID; got != want {
+		t.Errorf("serverInventoryIdentifier() = %q, want %q", got, want)
+	}
+
+	want := databaseComponentIdentifier + "/subscriptions/sub-a/resourcegroups/rg-a/providers/microsoft.dbforpostgresql/flexibleservers/server-a"
+	if got := serverComponentIdentifier(serverID); got != want {
+		t.Errorf("serverComponentIdentifier() = %q, want %q", got, want)
+	}
+	if got := serverComponentIdentifier("/subscriptions/sub-a/resourcegroups/rg-a/providers/microsoft.dbforpostgresql/flexibleservers/server-a"); got != want {
+		t.Errorf("serverComponentIdentifier(lower case) = %q, want %q", got, want)
+	}
+}
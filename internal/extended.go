@@ -0,0 +1,82 @@
+package internal
+
+// ExtendedServer is the subset of the flexible server resource, as returned by a newer ARM API version,
+// that the armpostgresqlflexibleservers SDK does not model.
+type ExtendedServer struct {
+	Identity   *ExtendedIdentity         `json:"identity,omitempty"`
+	Properties *ExtendedServerProperties `json:"properties,omitempty"`
+}
+
+type ExtendedServerProperties struct {
+	HighAvailability           *ExtendedHighAvailability           `json:"highAvailability,omitempty"`
+	Storage                    *ExtendedStorage                    `json:"storage,omitempty"`
+	AuthConfig                 *ExtendedAuthConfig                 `json:"authConfig,omitempty"`
+	ReplicationRole            *string                             `json:"replicationRole,omitempty"`
+	SourceServerResourceID     *string                             `json:"sourceServerResourceId,omitempty"`
+	Network                    *ExtendedNetwork                    `json:"network,omitempty"`
+	DataEncryption             *ExtendedDataEncryption             `json:"dataEncryption,omitempty"`
+	PrivateEndpointConnections []ExtendedPrivateEndpointConnection `json:"privateEndpointConnections,omitempty"`
+}
+
+type ExtendedDataEncryption struct {
+	Type                            *string `json:"type,omitempty"`
+	PrimaryKeyURI                   *string `json:"primaryKeyURI,omitempty"`
+	PrimaryUserAssignedIdentityID   *string `json:"primaryUserAssignedIdentityId,omitempty"`
+	PrimaryEncryptionKeyStatus      *string `json:"primaryEncryptionKeyStatus,omitempty"`
+	GeoBackupKeyURI                 *string `json:"geoBackupKeyURI,omitempty"`
+	GeoBackupUserAssignedIdentityID *string `json:"geoBackupUserAssignedIdentityId,omitempty"`
+	GeoBackupEncryptionKeyStatus    *string `json:"geoBackupEncryptionKeyStatus,omitempty"`
+}
+
+type ExtendedNetwork struct {
+	PublicNetworkAccess *string `json:"publicNetworkAccess,omitempty"`
+}
+
+type ExtendedPrivateEndpointConnection struct {
+	Name       *string `json:"name,omitempty"`
+	Properties *struct {
+		PrivateEndpoint *struct {
+			ID *string `json:"id,omitempty"`
+		} `json:"privateEndpoint,omitempty"`
+		PrivateLinkServiceConnectionState *struct {
+			Status      *string `json:"status,omitempty"`
+			Description *string `json:"description,omitempty"`
+		} `json:"privateLinkServiceConnectionState,omitempty"`
+		ProvisioningState *string `json:"provisioningState,omitempty"`
+	} `json:"properties,omitempty"`
+}
+
+type ExtendedHighAvailability struct {
+	Mode                    *string `json:"mode,omitempty"`
+	StandbyAvailabilityZone *string `json:"standbyAvailabilityZone,omitempty"`
+	State                   *string `json:"state,omitempty"`
+}
+
+type ExtendedAuthConfig struct {
+	ActiveDirectoryAuth *string `json:"activeDirectoryAuth,omitempty"`
+	PasswordAuth        *string `json:"passwordAuth,omitempty"`
+	TenantID            *string `json:"tenantId,omitempty"`
+}
+
+type ExtendedStorage struct {
+	AutoGrow      *string `json:"autoGrow,omitempty"`
+	Tier          *string `json:"tier,omitempty"`
+	Type          *string `json:"type,omitempty"`
+	StorageSizeGB *int32  `json:"storageSizeGB,omitempty"`
+	Iops          *int32  `json:"iops,omitempty"`
+	Throughput    *int32  `json:"throughput,omitempty"`
+}
+
+// GetExtendedServer fetches the server again using a newer API version to read the properties missing from the SDK model.
+func (dp *AzureDataProcessor) GetExtendedServer(serverID string) (*ExtendedServer, error) {
+	client, err := dp.getARMClient()
+	if err != nil {
+		return nil, err
+	}
+
+	extended := &ExtendedServer{}
+	if err := client.Get(dp.ctx, serverID, flexibleServersAPIVersion, extended); err != nil {
+		return nil, err
+	}
+	return extended, nil
+}
@@ -0,0 +1,139 @@
+package internal
+
+import (
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/postgresql/armpostgresqlflexibleservers"
+)
+
+// ServerFacts are best-practice checks derived once from the collected server data, so that policies don't
+// have to recompute them from the raw SDK structures. A missing fact means the inputs could not be determined.
+type ServerFacts struct {
+	HighAvailabilityEnabled     *bool `json:"high_availability_enabled,omitempty"`
+	ZoneRedundant               *bool `json:"zone_redundant,omitempty"`
+	HighAvailabilityAvailable   *bool `json:"high_availability_available,omitempty"`
+	ZoneRedundantHAAvailable    *bool `json:"zone_redundant_ha_available,omitempty"`
+	StorageAutoGrowEnabled      *bool `json:"storage_autogrow_enabled,omitempty"`
+	ProductionTier              *bool `json:"production_tier,omitempty"`
+	IsBurstable                 *bool `json:"is_burstable,omitempty"`
+	HAWithoutZoneRedundancy     *bool `json:"ha_without_zone_redundancy,omitempty"`
+	ProductionWithoutAutoGrow   *bool `json:"production_without_autogrow,omitempty"`
+	HasCrossRegionReplica       *bool `json:"has_cross_region_replica,omitempty"`
+	ReplicationOutsideGeography *bool `json:"replication_outside_geography,omitempty"`
+	DiscouragedAdminLogin       *bool `json:"discouraged_admin_login,omitempty"`
+	StorageTierMismatch         *bool `json:"storage_tier_mismatch,omitempty"`
+	AllowAllFirewallRule        *bool `json:"allow_all_firewall_rule,omitempty"`
+	AllowAzureServicesRule      *bool `json:"allow_azure_services_firewall_rule,omitempty"`
+	EntraAuthEnabled            *bool `json:"entra_auth_enabled,omitempty"`
+	PasswordAuthEnabled         *bool `json:"password_auth_enabled,omitempty"`
+	HasEntraAdministrator       *bool `json:"has_entra_administrator,omitempty"`
+	PasswordOnlyAuth            *bool `json:"password_only_auth,omitempty"`
+	RequireSecureTransport      *bool `json:"require_secure_transport,omitempty"`
+	PublicNetworkAccessEnabled  *bool `json:"public_network_access_enabled,omitempty"`
+	PrivateAccessOnly           *bool `json:"private_access_only,omitempty"`
+	HasPrivateEndpoint          *bool `json:"has_private_endpoint,omitempty"`
+	UnapprovedPrivateEndpoint   *bool `json:"unapproved_private_endpoint,omitempty"`
+	PrivateDNSZoneLinked        *bool `json:"private_dns_zone_linked,omitempty"`
+	HasDiagnosticSettings       *bool `json:"has_diagnostic_settings,omitempty"`
+	LogForwardingEnabled        *bool `json:"log_forwarding_enabled,omitempty"`
+	MetricForwardingEnabled     *bool `json:"metric_forwarding_enabled,omitempty"`
+	ThreatProtectionEnabled     *bool `json:"threat_protection_enabled,omitempty"`
+	CustomerManagedKey          *bool `json:"customer_managed_key,omitempty"`
+	EncryptionKeyExpired        *bool `json:"encryption_key_expired,omitempty"`
+	EncryptionKeyRotation       *bool `json:"encryption_key_rotation_enabled,omitempty"`
+	EncryptionIdentityAssigned  *bool `json:"encryption_identity_assigned,omitempty"`
+	GeoBackupKeyExpired         *bool `json:"geo_backup_key_expired,omitempty"`
+	CustomMaintenanceWindow     *bool `json:"custom_maintenance_window,omitempty"`
+	AzurePolicyNonCompliant     *bool `json:"azure_policy_non_compliant,omitempty"`
+	DefenderUnhealthy           *bool `json:"defender_unhealthy,omitempty"`
+	HasDeleteLock               *bool `json:"has_delete_lock,omitempty"`
+	PrivilegedRoleAtServerScope *bool `json:"privileged_role_at_server_scope,omitempty"`
+	MissingRequiredTags         *bool `json:"missing_required_tags,omitempty"`
+	MigrationInProgress         *bool `json:"migration_in_progress,omitempty"`
+	StalledMigration            *bool `json:"stalled_migration,omitempty"`
+	HasVirtualEndpoint          *bool `json:"has_virtual_endpoint,omitempty"`
+	ProhibitedExtensionAllowed  *bool `json:"prohibited_extension_allowed,omitempty"`
+	RequiredExtensionNotAllowed *bool `json:"required_extension_not_allowed,omitempty"`
+	SuperuserLoginRole          *bool `json:"superuser_login_role,omitempty"`
+	NonExpiringLoginRole        *bool `json:"non_expiring_login_role,omitempty"`
+
+	// SSLMinProtocolVersion is the minimum TLS version clients must use, e.g. TLSv1.2.
+	SSLMinProtocolVersion *string `json:"ssl_min_protocol_version,omitempty"`
+}
+
+// defaultDiscouragedAdminLogins are well known administrator names, used unless discouraged_admin_logins is configured.
+var defaultDiscouragedAdminLogins = []string{"postgres", "admin", "administrator", "azureuser", "root", "sa"}
+
+// IsDiscouragedAdminLogin reports whether the administrator login is one of the discouraged names, ignoring case.
+func IsDiscouragedAdminLogin(login string, discouraged []string) bool {
+	for _, name := range discouraged {
+		if strings.EqualFold(login, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// DeriveServerFacts computes the derived facts for a server. The extended server may be nil when it could not be fetched.
+func DeriveServerFacts(server *armpostgresqlflexibleservers.Server, extended *ExtendedServer) *ServerFacts {
+	facts := &ServerFacts{}
+
+	// Production tiers are every SKU tier other than Burstable, which Azure positions for dev/test workloads.
+	if server.SKU != nil && server.SKU.Tier != nil {
+		facts.ProductionTier = BoolAddressed(*server.SKU.Tier != armpostgresqlflexibleservers.SKUTierBurstable)
+		facts.IsBurstable = BoolAddressed(*server.SKU.Tier == armpostgresqlflexibleservers.SKUTierBurstable)
+	}
+
+	haMode, primaryZone, standbyZone := highAvailabilityMode(server, extended)
+	if haMode != "" {
+		haEnabled := !strings.EqualFold(haMode, string(armpostgresqlflexibleservers.HighAvailabilityModeDisabled))
+		// A zone redundant standby placed in the primary's zone gives no more protection than a same-zone standby.
+		zoneRedundant := strings.EqualFold(haMode, string(armpostgresqlflexibleservers.HighAvailabilityModeZoneRedundant)) &&
+			(standbyZone == "" || primaryZone == "" || standbyZone != primaryZone)
+
+		facts.HighAvailabilityEnabled = BoolAddressed(haEnabled)
+		facts.ZoneRedundant = BoolAddressed(zoneRedundant)
+		facts.HAWithoutZoneRedundancy = BoolAddressed(haEnabled && !zoneRedundant)
+	}
+
+	if extended != nil && extended.Properties != nil && extended.Properties.Storage != nil && extended.Properties.Storage.AutoGrow != nil {
+		facts.StorageAutoGrowEnabled = BoolAddressed(strings.EqualFold(*extended.Properties.Storage.AutoGrow, "Enabled"))
+	}
+
+	if facts.ProductionTier != nil && facts.StorageAutoGrowEnabled != nil {
+		facts.ProductionWithoutAutoGrow = BoolAddressed(*facts.ProductionTier && !*facts.StorageAutoGrowEnabled)
+	}
+
+	return facts
+}
+
+// highAvailabilityMode prefers the extended server's HA mode, as the SDK model predates the SameZone mode.
+func highAvailabilityMode(server *armpostgresqlflexibleservers.Server, extended *ExtendedServer) (string, string, string) {
+	var mode, primaryZone, standbyZone string
+
+	if server.Properties != nil {
+		if server.Properties.AvailabilityZone != nil {
+			primaryZone = *server.Properties.AvailabilityZone
+		}
+		if ha := server.Properties.HighAvailability; ha != nil {
+			if ha.Mode != nil {
+				mode = string(*ha.Mode)
+			}
+			if ha.StandbyAvailabilityZone != nil {
+				standbyZone = *ha.StandbyAvailabilityZone
+			}
+		}
+	}
+
+	if extended != nil && extended.Properties != nil && extended.Properties.HighAvailability != nil {
+		ha := extended.Properties.HighAvailability
+		if ha.Mode != nil {
+			mode = *ha.Mode
+		}
+		if ha.StandbyAvailabilityZone != nil {
+			standbyZone = *ha.StandbyAvailabilityZone
+		}
+	}
+
+	return mode, primaryZone, standbyZone
+}
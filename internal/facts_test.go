@@ -0,0 +1,116 @@
+package internal
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/postgresql/armpostgresqlflexibleservers"
+)
+
+// boolFact renders an optional fact for comparison, with nil meaning the fact couldn't be derived.
+func boolFact(b *bool) string {
+	if b == nil {
+		return "nil"
+	}
+	return fmt.Sprint(*b)
+}
+
+func TestDeriveServerFacts(t *testing.T) {
+	server := func(tier armpostgresqlflexibleservers.SKUTier, ha *armpostgresqlflexibleservers.HighAvailability, zone string) *armpostgresqlflexibleservers.Server {
+		s := &armpostgresqlflexibleservers.Server{
+			SKU:        &armpostgresqlflexibleservers.SKU{Tier: to.Ptr(tier)},
+			Properties: &armpostgresqlflexibleservers.ServerProperties{HighAvailability: ha},
+		}
+		if zone != "" {
+			s.Properties.AvailabilityZone = to.Ptr(zone)
+		}
+		return s
+	}
+	sdkHA := func(mode armpostgresqlflexibleservers.HighAvailabilityMode, standbyZone string) *armpostgresqlflexibleservers.HighAvailability {
+		ha := &armpostgresqlflexibleservers.HighAvailability{Mode: to.Ptr(mode)}
+		if standbyZone != "" {
+			ha.StandbyAvailabilityZone = to.Ptr(standbyZone)
+		}
+		return ha
+	}
+	extended := func(haMode *string, autoGrow *string) *ExtendedServer {
+		properties := &ExtendedServerProperties{Storage: &ExtendedStorage{AutoGrow: autoGrow}}
+		if haMode != nil {
+			properties.HighAvailability = &ExtendedHighAvailability{Mode: haMode}
+		}
+		return &ExtendedServer{Properties: properties}
+	}
+
+	tests := []struct {
+		name     string
+		server   *armpostgresqlflexibleservers.Server
+		extended *ExtendedServer
+		// want lists ProductionTier, IsBurstable, HighAvailabilityEnabled, ZoneRedundant, HAWithoutZoneRedundancy,
+		// StorageAutoGrowEnabled and ProductionWithoutAutoGrow in turn.
+		want [7]string
+	}{
+		{
+			name:   "burstable without extended server",
+			server: server(armpostgresqlflexibleservers.SKUTierBurstable, nil, ""),
+			want:   [7]string{"false", "true", "nil", "nil", "nil", "nil", "nil"},
+		},
+		{
+			name:     "burstable with auto-grow unset",
+			server:   server(armpostgresqlflexibleservers.SKUTierBurstable, sdkHA(armpostgresqlflexibleservers.HighAvailabilityModeDisabled, ""), ""),
+			extended: extended(nil, nil),
+			want:     [7]string{"false", "true", "false", "false", "false", "nil", "nil"},
+		},
+		{
+			name:     "general purpose with HA disabled and auto-grow enabled",
+			server:   server(armpostgresqlflexibleservers.SKUTierGeneralPurpose, sdkHA(armpostgresqlflexibleservers.HighAvailabilityModeDisabled, ""), "1"),
+			extended: extended(nil, to.Ptr("Enabled")),
+			want:     [7]string{"true", "false", "false", "false", "false", "true", "false"},
+		},
+		{
+			name:     "general purpose with same zone HA and auto-grow disabled",
+			server:   server(armpostgresqlflexibleservers.SKUTierGeneralPurpose, nil, "1"),
+			extended: extended(to.Ptr("SameZone"), to.Ptr("Disabled")),
+			want:     [7]string{"true", "false", "true", "false", "true", "false", "true"},
+		},
+		{
+			name:   "zone redundant HA across zones",
+			server: server(armpostgresqlflexibleservers.SKUTierGeneralPurpose, sdkHA(armpostgresqlflexibleservers.HighAvailabilityModeZoneRedundant, "2"), "1"),
+			want:   [7]string{"true", "false", "true", "true", "false", "nil", "nil"},
+		},
+		{
+			name:   "zone redundant HA with the standby in the primary's zone",
+			server: server(armpostgresqlflexibleservers.SKUTierGeneralPurpose, sdkHA(armpostgresqlflexibleservers.HighAvailabilityModeZoneRedundant, "1"), "1"),
+			want:   [7]string{"true", "false", "true", "false", "true", "nil", "nil"},
+		},
+		{
+			name:     "extended HA mode takes precedence",
+			server:   server(armpostgresqlflexibleservers.SKUTierMemoryOptimized, sdkHA(armpostgresqlflexibleservers.HighAvailabilityModeZoneRedundant, "2"), "1"),
+			extended: extended(to.Ptr("SameZone"), to.Ptr("enabled")),
+			want:     [7]string{"true", "false", "true", "false", "true", "true", "false"},
+		},
+		{
+			name:   "no SKU or properties",
+			server: &armpostgresqlflexibleservers.Server{},
+			want:   [7]string{"nil", "nil", "nil", "nil", "nil", "nil", "nil"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			facts := DeriveServerFacts(tt.server, tt.extended)
+			got := [7]string{
+				boolFact(facts.ProductionTier),
+				boolFact(facts.IsBurstable),
+				boolFact(facts.HighAvailabilityEnabled),
+				boolFact(facts.ZoneRedundant),
+				boolFact(facts.HAWithoutZoneRedundancy),
+				boolFact(facts.StorageAutoGrowEnabled),
+				boolFact(facts.ProductionWithoutAutoGrow),
+			}
+			if got != tt.want {
+				t.Errorf("facts = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package internal
+
+import (
+	"fmt"
+	"net/netip"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/postgresql/armpostgresqlflexibleservers"
+)
+
+// A rule from 0.0.0.0 to 0.0.0.0 is how Azure records "Allow public access from any Azure service", which lets in
+// connections from any Azure tenant's resources, not just the server owner's.
+const azureServicesIPAddress = "0.0.0.0"
+
+// A rule covering every public unicast address, 1.0.0.0 to 223.255.255.255, is as open as 0.0.0.0/0.
+var (
+	firstPublicIPAddress = netip.MustParseAddr("1.0.0.0")
+	lastPublicIPAddress  = netip.MustParseAddr("223.255.255.255")
+)
+
+// FirewallRule is a server firewall rule allowing an IPv4 range to connect to the server.
+type FirewallRule struct {
+	// ID is the rule's resource ID, so a finding can link to the exact rule.
+	ID             string `json:"id"`
+	Name           string `json:"name"`
+	StartIPAddress string `json:"start_ip_address"`
+	EndIPAddress   string `json:"end_ip_address"`
+	// AllowAll is true when the rule allows connections from any public IPv4 address, such as 0.0.0.0 to
+	// 255.255.255.255.
+	AllowAll bool `json:"allow_all"`
+	// AllowAzureServices is true for the 0.0.0.0 to 0.0.0.0 rule allowing connections from any Azure service.
+	AllowAzureServices bool `json:"allow_azure_services"`
+}
+
+// IPRange returns the rule's range in start-end form.
+func (r FirewallRule) IPRange() string {
+	return fmt.Sprintf("%s-%s", r.StartIPAddress, r.EndIPAddress)
+}
+
+// GetFirewallRules lists the firewall rules of a server. A server without firewall rules returns an empty list.
+func (dp *AzureDataProcessor) GetFirewallRules(serverID string) ([]FirewallRule, error) {
+	idparts, err := ParseAzureResourceID(serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := armpostgresqlflexibleservers.NewFirewallRulesClient(idparts.SubscriptionID(), dp.credential, dp.clientOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make([]FirewallRule, 0)
+	pager := client.NewListByServerPager(idparts.ResourceGroup(), idparts.Name, nil)
+	for pager.More() {
+		page, err := pager.NextPage(dp.ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, rule := range page.Value {
+			rules = append(rules, newFirewallRule(rule))
+		}
+	}
+	return rules, nil
+}
+
+func newFirewallRule(rule *armpostgresqlflexibleservers.FirewallRule) FirewallRule {
+	r := FirewallRule{}
+	if rule.ID != nil {
+		r.ID = *rule.ID
+	}
+	if rule.Name != nil {
+		r.Name = *rule.Name
+	}
+	if rule.Properties != nil {
+		if rule.Properties.StartIPAddress != nil {
+			r.StartIPAddress = *rule.Properties.StartIPAddress
+		}
+		if rule.Properties.EndIPAddress != nil {
+			r.EndIPAddress = *rule.Properties.EndIPAddress
+		}
+	}
+	r.AllowAzureServices = r.StartIPAddress == azureServicesIPAddress && r.EndIPAddress == azureServicesIPAddress
+	r.AllowAll = coversPublicAddresses(r.StartIPAddress, r.EndIPAddress)
+	return r
+}
+
+// coversPublicAddresses reports whether the range covers every public unicast IPv4 address. Unparseable addresses
+// cover nothing.
+func coversPublicAddresses(start string, end string) bool {
+	startIP, err := netip.ParseAddr(start)
+	if err != nil || !startIP.Is4() {
+		return false
+	}
+	endIP, err := netip.ParseAddr(end)
+	if err != nil || !endIP.Is4() {
+		return false
+	}
+	return startIP.Compare(firstPublicIPAddress) <= 0 && endIP.Compare(lastPublicIPAddress) >= 0
+}
+
+// HasAllowAllFirewallRule reports whether any rule allows connections from any IPv4 address.
+func HasAllowAllFirewallRule(rules []FirewallRule) bool {
+	for _, rule := range rules {
+		if rule.AllowAll {
+			return true
+		}
+	}
+	return false
+}
+
+// HasAllowAzureServicesFirewallRule reports whether any rule allows connections from any Azure service.
+func HasAllowAzureServicesFirewallRule(rules []FirewallRule) bool {
+	for _, rule := range rules {
+		if rule.AllowAzureServices {
+			return true
+		}
+	}
+	return false
+}
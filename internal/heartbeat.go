@@ -0,0 +1,63 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/compliance-framework/agent/runner/proto"
+)
+
+// emitHeartbeats records a subscription that was listed successfully but had no servers to assess, whether it has
+// none or the configured filters excluded them all. Without it, such a run leaves no trace in the evidence.
+// Subscriptions that failed to list are left to the error report.
+func (dp *AzureDataProcessor) emitHeartbeats(run *serverRun, listFailures map[string]bool) {
+	for _, subscriptionID := range dp.subscriptionIDs() {
+		if listFailures[subscriptionID] || run.assessed[strings.ToLower(subscriptionID)] > 0 {
+			continue
+		}
+
+		ec := newRunEvidenceContext(subscriptionID, run.activities)
+		evidence, err := ec.NewEvidence(
+			"builtin_heartbeat",
+			fmt.Sprintf("No Azure PostgreSQL servers to assess in subscription %s.", subscriptionID),
+			dp.heartbeatDescription(subscriptionID),
+			&proto.EvidenceStatus{
+				Reason:  "pass",
+				Remarks: "Collection ran and found zero servers.",
+				State:   proto.EvidenceStatusState_EVIDENCE_STATUS_STATE_SATISFIED,
+			},
+		)
+		if err != nil {
+			dp.logger.Error("Error creating heartbeat evidence", "subscription", subscriptionID, "error", err)
+			continue
+		}
+
+		dp.logger.Info("No servers to assess, emitting heartbeat evidence", "subscription", subscriptionID)
+		if err := dp.writeEvidence([]*proto.Evidence{evidence}); err != nil {
+			dp.logger.Error("Error creating heartbeat evidence", "subscription", subscriptionID, "error", err)
+			run.errs.Add(subscriptionID, "create evidence", "", err)
+			run.failed.Store(true)
+		}
+	}
+}
+
+// heartbeatDescription describes what a run found nothing of in a subscription, naming the kinds of server listed
+// and only the filters that are configured.
+func (dp *AzureDataProcessor) heartbeatDescription(subscriptionID string) string {
+	filters := make([]string, 0)
+	if len(dp.resourceGroups()) > 0 {
+		filters = append(filters, "resource group")
+	}
+	if dp.config["tag_filter"] != "" || dp.config["tag_window_filter"] != "" {
+		filters = append(filters, "tag")
+	}
+	if dp.config["server_name_include"] != "" || dp.config["server_name_exclude"] != "" {
+		filters = append(filters, "server name")
+	}
+
+	description := fmt.Sprintf("The plugin collected subscription %s successfully and found no %s", subscriptionID, ServerKindsFromConfig(dp.config).Describe())
+	if len(filters) > 0 {
+		description += fmt.Sprintf(" matching the configured %s filters", joinWords(filters, "and"))
+	}
+	return description + "."
+}
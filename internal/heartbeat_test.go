@@ -0,0 +1,54 @@
+package internal
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+func TestHeartbeatDescription(t *testing.T) {
+	tests := []struct {
+		name   string
+		config map[string]string
+		want   string
+	}{
+		{
+			name:   "no filters",
+			config: map[string]string{},
+			want:   "The plugin collected subscription sub-a successfully and found no Azure PostgreSQL Flexible Servers.",
+		},
+		{
+			name:   "resource group filter",
+			config: map[string]string{"resource_groups": "rg-a"},
+			want:   "The plugin collected subscription sub-a successfully and found no Azure PostgreSQL Flexible Servers matching the configured resource group filters.",
+		},
+		{
+			name: "every filter and kind",
+			config: map[string]string{
+				"resource_groups":       "rg-a",
+				"tag_window_filter":     "window=a",
+				"server_name_exclude":   "^test-",
+				"include_single_server": "true",
+				"include_clusters":      "true",
+				"include_arc_servers":   "true",
+			},
+			want: "The plugin collected subscription sub-a successfully and found no Azure PostgreSQL Flexible Servers, single servers, " +
+				"Azure Cosmos DB for PostgreSQL clusters or Azure Arc-enabled PostgreSQL instances matching the configured resource group, tag and server name filters.",
+		},
+		{
+			name:   "single servers with a tag filter",
+			config: map[string]string{"include_single_server": "true", "tag_filter": "env=prod"},
+			want:   "The plugin collected subscription sub-a successfully and found no Azure PostgreSQL Flexible Servers or single servers matching the configured tag filters.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dp := NewAzureDataProcessor(context.Background(), hclog.NewNullLogger(), tt.config, nil, nil)
+			if got := dp.heartbeatDescription("sub-a"); got != tt.want {
+				t.Errorf("heartbeatDescription() =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
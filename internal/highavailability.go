@@ -0,0 +1,34 @@
+package internal
+
+import (
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/postgresql/armpostgresqlflexibleservers"
+)
+
+// HighAvailability is a server's high availability configuration in the policy input.
+type HighAvailability struct {
+	// Mode is Disabled, SameZone or ZoneRedundant.
+	Mode string `json:"mode,omitempty"`
+	// State is the standby's state, e.g. Healthy, CreatingStandby, ReplicatingData, FailingOver or NotEnabled.
+	State                   string `json:"state,omitempty"`
+	AvailabilityZone        string `json:"availability_zone,omitempty"`
+	StandbyAvailabilityZone string `json:"standby_availability_zone,omitempty"`
+}
+
+// NewHighAvailability summarises the server's high availability configuration. The extended server may be nil when
+// it could not be fetched, in which case the SDK model is used, which doesn't know the SameZone mode.
+func NewHighAvailability(server *armpostgresqlflexibleservers.Server, extended *ExtendedServer) *HighAvailability {
+	mode, primaryZone, standbyZone := highAvailabilityMode(server, extended)
+	ha := &HighAvailability{
+		Mode:                    mode,
+		AvailabilityZone:        primaryZone,
+		StandbyAvailabilityZone: standbyZone,
+	}
+
+	if server.Properties != nil && server.Properties.HighAvailability != nil && server.Properties.HighAvailability.State != nil {
+		ha.State = string(*server.Properties.HighAvailability.State)
+	}
+	if extended != nil && extended.Properties != nil && extended.Properties.HighAvailability != nil && extended.Properties.HighAvailability.State != nil {
+		ha.State = *extended.Properties.HighAvailability.State
+	}
+	return ha
+}
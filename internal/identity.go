@@ -0,0 +1,89 @@
+package internal
+
+import (
+	"sort"
+	"strings"
+)
+
+// ManagedIdentities are the managed identities attached to a server, which it uses to reach Key Vault for
+// customer-managed keys.
+type ManagedIdentities struct {
+	// Type is None, SystemAssigned, UserAssigned or SystemAssigned,UserAssigned.
+	Type string `json:"type"`
+	// PrincipalID and TenantID are the system-assigned identity's, only set when the server has one.
+	PrincipalID string `json:"principal_id,omitempty"`
+	TenantID    string `json:"tenant_id,omitempty"`
+	// UserAssigned is always present, so policies see an empty list for servers without user-assigned identities.
+	UserAssigned []UserAssignedIdentity `json:"user_assigned"`
+}
+
+// UserAssignedIdentity is a user-assigned managed identity attached to a server.
+type UserAssignedIdentity struct {
+	ID          string `json:"id"`
+	PrincipalID string `json:"principal_id,omitempty"`
+	ClientID    string `json:"client_id,omitempty"`
+}
+
+type ExtendedIdentity struct {
+	Type                   *string `json:"type,omitempty"`
+	PrincipalID            *string `json:"principalId,omitempty"`
+	TenantID               *string `json:"tenantId,omitempty"`
+	UserAssignedIdentities map[string]*struct {
+		PrincipalID *string `json:"principalId,omitempty"`
+		ClientID    *string `json:"clientId,omitempty"`
+	} `json:"userAssignedIdentities,omitempty"`
+}
+
+// NewManagedIdentities summarises the server's managed identities. It returns nil when the extended server is
+// unavailable.
+func NewManagedIdentities(extended *ExtendedServer) *ManagedIdentities {
+	if extended == nil {
+		return nil
+	}
+
+	identities := &ManagedIdentities{
+		Type:         "None",
+		UserAssigned: make([]UserAssignedIdentity, 0),
+	}
+	source := extended.Identity
+	if source == nil {
+		return identities
+	}
+	if source.Type != nil {
+		identities.Type = *source.Type
+	}
+	if source.PrincipalID != nil {
+		identities.PrincipalID = *source.PrincipalID
+	}
+	if source.TenantID != nil {
+		identities.TenantID = *source.TenantID
+	}
+	for id, identity := range source.UserAssignedIdentities {
+		userAssigned := UserAssignedIdentity{ID: id}
+		if identity != nil {
+			if identity.PrincipalID != nil {
+				userAssigned.PrincipalID = *identity.PrincipalID
+			}
+			if identity.ClientID != nil {
+				userAssigned.ClientID = *identity.ClientID
+			}
+		}
+		identities.UserAssigned = append(identities.UserAssigned, userAssigned)
+	}
+	// Azure returns the identities as a map, so they are sorted for stable evidence.
+	sort.Slice(identities.UserAssigned, func(i, j int) bool {
+		return identities.UserAssigned[i].ID < identities.UserAssigned[j].ID
+	})
+	return identities
+}
+
+// HasUserAssigned reports whether the user-assigned identity is attached to the server. Resource IDs are compared
+// case-insensitively.
+func (m *ManagedIdentities) HasUserAssigned(id string) bool {
+	for _, identity := range m.UserAssigned {
+		if strings.EqualFold(identity.ID, id) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,34 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/open-policy-agent/opa/v1/ast"
+)
+
+const inlinePolicyFile = "inline_policy.rego"
+
+// PrepareInlinePolicy compiles the Rego source from the inline_policy config and writes it to a temporary policy
+// directory that can be evaluated like any other policy path. The returned cleanup function removes the directory.
+func PrepareInlinePolicy(source string) (string, func(), error) {
+	if _, err := ast.CompileModules(map[string]string{inlinePolicyFile: source}); err != nil {
+		return "", nil, fmt.Errorf("inline_policy failed to compile: %w", err)
+	}
+
+	dir, err := os.MkdirTemp("", "plugin-azure-db-psql-inline-")
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to create inline policy directory: %w", err)
+	}
+	cleanup := func() {
+		_ = os.RemoveAll(dir)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, inlinePolicyFile), []byte(source), 0o600); err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("unable to write inline policy: %w", err)
+	}
+
+	return dir, cleanup, nil
+}
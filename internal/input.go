@@ -0,0 +1,110 @@
+package internal
+
+import (
+	"encoding/json"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/postgresql/armpostgresqlflexibleservers"
+)
+
+// ServerData is the document passed to the policy manager for each server.
+// The Azure SDK server object is passed through as-is, and anything the plugin collects or derives on
+// top of it is merged into the same top-level object using snake_case keys.
+type ServerData struct {
+	*armpostgresqlflexibleservers.Server
+	ServerExtensions
+}
+
+// ServerExtensions holds the data the plugin adds to the policy input on top of the Azure SDK server object.
+type ServerExtensions struct {
+	// ServerType is flexible, single, cluster or arc, so a single ruleset can branch on the kind of server.
+	ServerType string `json:"server_type"`
+	// NormalisedTags are the server's tags with lower-cased keys. Azure's own input.tags keeps the keys as they were set.
+	NormalisedTags map[string]string `json:"normalised_tags"`
+	// MissingTags are the required_tags the server doesn't carry, only set when required_tags is configured.
+	MissingTags []string `json:"missing_tags,omitempty"`
+	// Environment is the value of the server's environment tag, so policies can hold production servers to a higher bar.
+	Environment       string                         `json:"environment,omitempty"`
+	SingleServer      *SingleServerProperties        `json:"single_server,omitempty"`
+	Cluster           *ClusterProperties             `json:"cluster,omitempty"`
+	Arc               *ArcProperties                 `json:"arc,omitempty"`
+	Facts             *ServerFacts                   `json:"facts,omitempty"`
+	Extensions        *ExtensionAllowlist            `json:"extensions,omitempty"`
+	SSL               *SSLPosture                    `json:"ssl,omitempty"`
+	Locks             []ManagementLock               `json:"locks,omitempty"`
+	Replicas          []Replica                      `json:"replicas,omitempty"`
+	Replication       *Replication                   `json:"replication,omitempty"`
+	Configurations    map[string]ServerConfiguration `json:"configurations,omitempty"`
+	Logging           *LoggingSettings               `json:"logging,omitempty"`
+	ConnectionPooling *ConnectionPooling             `json:"connection_pooling,omitempty"`
+	Maintenance       *ServerMaintenanceWindow       `json:"maintenance_window,omitempty"`
+	HighAvailability  *HighAvailability              `json:"high_availability,omitempty"`
+	// LocationCapabilities is what the server's region offers, set when it could be read.
+	LocationCapabilities *LocationCapabilities `json:"location_capabilities,omitempty"`
+	Storage              *StorageConfig        `json:"storage,omitempty"`
+	Backup               *BackupConfig         `json:"backup,omitempty"`
+	AuthConfig           *AuthConfig           `json:"auth_config,omitempty"`
+	Administrators       []Administrator       `json:"administrators,omitempty"`
+	Network              *NetworkConfig        `json:"network,omitempty"`
+	ThreatProtection     *ThreatProtection     `json:"threat_protection,omitempty"`
+	DataEncryption       *DataEncryption       `json:"data_encryption,omitempty"`
+	ManagedIdentities    *ManagedIdentities    `json:"managed_identities,omitempty"`
+	// AdvisorRecommendations is only set when collect_advisor_recommendations is enabled.
+	AdvisorRecommendations []AdvisorRecommendation `json:"advisor_recommendations,omitempty"`
+	// PolicyStates is only set when collect_policy_states is enabled.
+	PolicyStates []PolicyState `json:"azure_policy_states,omitempty"`
+	// DefenderAssessments is only set when collect_defender_assessments is enabled.
+	DefenderAssessments []DefenderAssessment `json:"defender_assessments,omitempty"`
+	// RoleAssignments is only set when collect_role_assignments is enabled.
+	RoleAssignments []RoleAssignment `json:"role_assignments,omitempty"`
+	// ActivityLog is only set when activity_log_days is configured.
+	ActivityLog []ActivityLogOperation `json:"activity_log,omitempty"`
+	// DatabaseRoles is only set when db_connect is enabled.
+	DatabaseRoles []DatabaseRole `json:"database_roles,omitempty"`
+	// SQLProbes is only set when db_connect is enabled and db_probes are configured.
+	SQLProbes map[string]SQLProbeResult `json:"sql_probes,omitempty"`
+	// Migrations is only set when collect_migrations is enabled.
+	Migrations []Migration `json:"migrations,omitempty"`
+	// FirewallRules is always present once collected, so policies see an empty list for servers without rules.
+	FirewallRules []FirewallRule `json:"firewall_rules"`
+	// Databases is always present once collected, listing every database on the server.
+	Databases []Database `json:"databases"`
+	// VirtualEndpoints is always present once collected, so policies see an empty list for servers without them.
+	VirtualEndpoints []VirtualEndpoint `json:"virtual_endpoints"`
+	// DiagnosticSettings is always present once collected, so servers without diagnostic settings still evaluate.
+	DiagnosticSettings []DiagnosticSetting `json:"diagnostic_settings"`
+
+	// storageMismatch is the storage tier or type mismatched with the SKU tier, used by the built-in storage check.
+	storageMismatch *string
+	// warnings are the optional collections that failed for the server.
+	warnings []CollectionWarning
+}
+
+// MarshalJSON flattens the server and its extensions into a single object.
+// The SDK server type implements its own MarshalJSON, which would otherwise be promoted and drop the extensions.
+func (s ServerData) MarshalJSON() ([]byte, error) {
+	result := map[string]json.RawMessage{}
+
+	if s.Server != nil {
+		serverJSON, err := json.Marshal(s.Server)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(serverJSON, &result); err != nil {
+			return nil, err
+		}
+	}
+
+	extensionsJSON, err := json.Marshal(s.ServerExtensions)
+	if err != nil {
+		return nil, err
+	}
+	extensions := map[string]json.RawMessage{}
+	if err := json.Unmarshal(extensionsJSON, &extensions); err != nil {
+		return nil, err
+	}
+	for key, value := range extensions {
+		result[key] = value
+	}
+
+	return json.Marshal(result)
+}
@@ -0,0 +1,165 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+)
+
+// keyVaultAPIVersion is the Key Vault data plane API version. Keys are read through a raw pipeline, as the plugin
+// does not depend on the azkeys SDK.
+const keyVaultAPIVersion = "7.4"
+
+// KeyVaultKey is the metadata of a Key Vault key used to encrypt a server. The key material itself is never read.
+type KeyVaultKey struct {
+	KeyID string `json:"key_id"`
+	// KeyType is e.g. RSA or RSA-HSM.
+	KeyType string     `json:"key_type,omitempty"`
+	Enabled bool       `json:"enabled"`
+	Expires *time.Time `json:"expires,omitempty"`
+	// RotationEnabled is true when the key's rotation policy rotates it automatically. It is omitted when the
+	// rotation policy can't be read.
+	RotationEnabled *bool `json:"rotation_enabled,omitempty"`
+}
+
+// Expired reports whether the key has an expiry date that has passed.
+func (k *KeyVaultKey) Expired(now time.Time) bool {
+	return k.Expires != nil && k.Expires.Before(now)
+}
+
+type keyVaultKeyBundle struct {
+	Key *struct {
+		KID *string `json:"kid"`
+		Kty *string `json:"kty"`
+	} `json:"key"`
+	Attributes *struct {
+		Enabled *bool  `json:"enabled"`
+		Exp     *int64 `json:"exp"`
+	} `json:"attributes"`
+}
+
+type keyVaultRotationPolicy struct {
+	LifetimeActions []struct {
+		Action *struct {
+			Type *string `json:"type"`
+		} `json:"action"`
+	} `json:"lifetimeActions"`
+}
+
+// KeyVaultClient reads key metadata from Key Vault data plane endpoints, in whichever cloud the key URI points to.
+type KeyVaultClient struct {
+	credential azcore.TokenCredential
+	options    *arm.ClientOptions
+}
+
+func NewKeyVaultClient(credential azcore.TokenCredential, options *arm.ClientOptions) *KeyVaultClient {
+	return &KeyVaultClient{
+		credential: credential,
+		options:    options,
+	}
+}
+
+// GetKey fetches the metadata of the key a key URI refers to, e.g. https://vault.vault.azure.net/keys/name/version.
+// A URI without a version refers to the key's current version.
+func (c *KeyVaultClient) GetKey(ctx context.Context, keyURI string) (*KeyVaultKey, error) {
+	parsed, err := url.Parse(keyURI)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key URI %q: %w", keyURI, err)
+	}
+	segments := strings.Split(strings.Trim(parsed.Path, "/"), "/")
+	if len(segments) < 2 || segments[0] != "keys" {
+		return nil, fmt.Errorf("invalid key URI %q: expected https://<vault>/keys/<name>[/<version>]", keyURI)
+	}
+
+	pipeline, err := c.pipeline(parsed.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := &keyVaultKeyBundle{}
+	if err := c.get(ctx, pipeline, keyURI, bundle); err != nil {
+		return nil, err
+	}
+
+	key := &KeyVaultKey{KeyID: keyURI}
+	if bundle.Key != nil {
+		if bundle.Key.KID != nil {
+			key.KeyID = *bundle.Key.KID
+		}
+		if bundle.Key.Kty != nil {
+			key.KeyType = *bundle.Key.Kty
+		}
+	}
+	if bundle.Attributes != nil {
+		if bundle.Attributes.Enabled != nil {
+			key.Enabled = *bundle.Attributes.Enabled
+		}
+		if bundle.Attributes.Exp != nil {
+			expires := time.Unix(*bundle.Attributes.Exp, 0).UTC()
+			key.Expires = &expires
+		}
+	}
+
+	// Reading the rotation policy needs a separate key permission, so failing to read it leaves rotation unknown
+	// rather than losing the rest of the key's metadata.
+	rotation := &keyVaultRotationPolicy{}
+	policyURI := fmt.Sprintf("%s://%s/keys/%s/rotationpolicy", parsed.Scheme, parsed.Host, segments[1])
+	if err := c.get(ctx, pipeline, policyURI, rotation); err == nil {
+		rotates := false
+		for _, action := range rotation.LifetimeActions {
+			if action.Action != nil && action.Action.Type != nil && strings.EqualFold(*action.Action.Type, "Rotate") {
+				rotates = true
+			}
+		}
+		key.RotationEnabled = &rotates
+	}
+
+	return key, nil
+}
+
+// pipeline builds a pipeline authenticating against the vault's own audience, e.g. https://vault.azure.net for
+// myvault.vault.azure.net, so keys in sovereign clouds are read with the right token.
+func (c *KeyVaultClient) pipeline(host string) (runtime.Pipeline, error) {
+	_, suffix, found := strings.Cut(host, ".")
+	if !found {
+		return runtime.Pipeline{}, fmt.Errorf("invalid Key Vault host %q", host)
+	}
+
+	options := &policy.ClientOptions{}
+	if c.options != nil {
+		options.Retry = c.options.Retry
+		options.Transport = c.options.Transport
+	}
+	authPolicy := runtime.NewBearerTokenPolicy(c.credential, []string{"https://" + suffix + "/.default"}, nil)
+	return runtime.NewPipeline(armModuleName, armModuleVersion, runtime.PipelineOptions{
+		PerRetry: []policy.Policy{authPolicy},
+	}, options), nil
+}
+
+func (c *KeyVaultClient) get(ctx context.Context, pipeline runtime.Pipeline, endpoint string, out any) error {
+	req, err := runtime.NewRequest(ctx, http.MethodGet, endpoint)
+	if err != nil {
+		return err
+	}
+	reqQP := req.Raw().URL.Query()
+	reqQP.Set("api-version", keyVaultAPIVersion)
+	req.Raw().URL.RawQuery = reqQP.Encode()
+	req.Raw().Header["Accept"] = []string{"application/json"}
+
+	resp, err := pipeline.Do(req)
+	if err != nil {
+		return err
+	}
+	if !runtime.HasStatusCode(resp, http.StatusOK) {
+		return runtime.NewResponseError(resp)
+	}
+	return runtime.UnmarshalAsJSON(resp, out)
+}
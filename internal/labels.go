@@ -0,0 +1,78 @@
+package internal
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+// LabelSanitizer cleans up label values so they satisfy backend constraints. Values are always trimmed and stripped
+// of control characters; truncation and replacement of disallowed characters only apply when configured.
+type LabelSanitizer struct {
+	maxLength   int
+	disallowed  *regexp.Regexp
+	replacement string
+}
+
+func NewLabelSanitizer(config map[string]string) (*LabelSanitizer, error) {
+	maxLength, err := ConfigInt(config, "label_value_max_length", 0)
+	if err != nil {
+		return nil, err
+	}
+	if maxLength < 0 {
+		return nil, fmt.Errorf("label_value_max_length must not be negative")
+	}
+
+	sanitizer := &LabelSanitizer{
+		maxLength:   maxLength,
+		replacement: "_",
+	}
+
+	if pattern := config["label_value_disallowed_pattern"]; pattern != "" {
+		disallowed, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("label_value_disallowed_pattern is not a valid regular expression: %w", err)
+		}
+		sanitizer.disallowed = disallowed
+	}
+	if replacement, ok := config["label_value_replacement"]; ok {
+		sanitizer.replacement = replacement
+	}
+
+	return sanitizer, nil
+}
+
+// Sanitize returns the cleaned up value.
+func (s *LabelSanitizer) Sanitize(value string) string {
+	value = strings.TrimSpace(strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, value))
+
+	if s.disallowed != nil {
+		value = s.disallowed.ReplaceAllLiteralString(value, s.replacement)
+	}
+
+	if s.maxLength > 0 && utf8.RuneCountInString(value) > s.maxLength {
+		value = string([]rune(value)[:s.maxLength])
+	}
+
+	return value
+}
+
+// SanitizeLabels cleans up every value in the labels in place, logging each value it changes.
+func (s *LabelSanitizer) SanitizeLabels(logger hclog.Logger, labels map[string]string) {
+	for key, value := range labels {
+		sanitized := s.Sanitize(value)
+		if sanitized != value {
+			logger.Info("Sanitized label value", "label", key, "original", value, "sanitized", sanitized)
+			labels[key] = sanitized
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package internal
+
+import (
+	"regexp"
+	"strings"
+)
+
+// regionCodes maps lower cased Azure region display names to their canonical region codes. Most display names are
+// spaced forms of the code, but aliases and geography prefixed names used by some APIs and the portal are not.
+var regionCodes = map[string]string{
+	"east us":              "eastus",
+	"east us 2":            "eastus2",
+	"central us":           "centralus",
+	"north central us":     "northcentralus",
+	"south central us":     "southcentralus",
+	"west central us":      "westcentralus",
+	"west us":              "westus",
+	"west us 2":            "westus2",
+	"west us 3":            "westus3",
+	"canada central":       "canadacentral",
+	"canada east":          "canadaeast",
+	"brazil south":         "brazilsouth",
+	"brazil southeast":     "brazilsoutheast",
+	"mexico central":       "mexicocentral",
+	"uk south":             "uksouth",
+	"uk west":              "ukwest",
+	"north europe":         "northeurope",
+	"west europe":          "westeurope",
+	"france central":       "francecentral",
+	"france south":         "francesouth",
+	"germany west central": "germanywestcentral",
+	"germany north":        "germanynorth",
+	"switzerland north":    "switzerlandnorth",
+	"switzerland west":     "switzerlandwest",
+	"norway east":          "norwayeast",
+	"norway west":          "norwaywest",
+	"sweden central":       "swedencentral",
+	"sweden south":         "swedensouth",
+	"poland central":       "polandcentral",
+	"italy north":          "italynorth",
+	"spain central":        "spaincentral",
+	"east asia":            "eastasia",
+	"southeast asia":       "southeastasia",
+	"japan east":           "japaneast",
+	"japan west":           "japanwest",
+	"korea central":        "koreacentral",
+	"korea south":          "koreasouth",
+	"central india":        "centralindia",
+	"south india":          "southindia",
+	"west india":           "westindia",
+	"jio india west":       "jioindiawest",
+	"jio india central":    "jioindiacentral",
+	"australia east":       "australiaeast",
+	"australia southeast":  "australiasoutheast",
+	"australia central":    "australiacentral",
+	"australia central 2":  "australiacentral2",
+	"new zealand north":    "newzealandnorth",
+	"uae north":            "uaenorth",
+	"uae central":          "uaecentral",
+	"qatar central":        "qatarcentral",
+	"israel central":       "israelcentral",
+	"south africa north":   "southafricanorth",
+	"south africa west":    "southafricawest",
+	"us gov virginia":      "usgovvirginia",
+	"us gov arizona":       "usgovarizona",
+	"us gov texas":         "usgovtexas",
+	"us dod central":       "usdodcentral",
+	"us dod east":          "usdodeast",
+	"china east":           "chinaeast",
+	"china east 2":         "chinaeast2",
+	"china east 3":         "chinaeast3",
+	"china north":          "chinanorth",
+	"china north 2":        "chinanorth2",
+	"china north 3":        "chinanorth3",
+
+	// Aliases with the geography first, as used by some pricing and monitoring APIs.
+	"us east":          "eastus",
+	"us east 2":        "eastus2",
+	"us central":       "centralus",
+	"us west":          "westus",
+	"us west 2":        "westus2",
+	"us west 3":        "westus3",
+	"us north central": "northcentralus",
+	"us south central": "southcentralus",
+	"us west central":  "westcentralus",
+	"europe west":      "westeurope",
+	"europe north":     "northeurope",
+	"asia east":        "eastasia",
+	"asia southeast":   "southeastasia",
+}
+
+// geographyPrefix matches the geography prefix the portal puts on display names, e.g. "(Europe) West Europe".
+var geographyPrefix = regexp.MustCompile(`^\([^)]*\)\s*`)
+
+// normaliseLocation converts a location, as a region code or display name, to its canonical region code, so
+// that it matches the region codes reported by other services and plugins. For example, "UK South", "(Europe) UK South"
+// and "uksouth" are all treated as "uksouth". Unknown locations are lower cased with all spaces removed.
+func normaliseLocation(location string) string {
+	name := strings.ToLower(geographyPrefix.ReplaceAllString(strings.TrimSpace(location), ""))
+	name = strings.Join(strings.Fields(name), " ")
+	if code, ok := regionCodes[name]; ok {
+		return code
+	}
+	return strings.ReplaceAll(name, " ", "")
+}
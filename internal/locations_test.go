@@ -0,0 +1,32 @@
+package internal
+
+import "testing"
+
+func TestNormaliseLocation(t *testing.T) {
+	tests := []struct {
+		location string
+		want     string
+	}{
+		{"UK South", "uksouth"},
+		{"East US 2", "eastus2"},
+		{"West Europe", "westeurope"},
+		{"Central US", "centralus"},
+		{"(Europe) UK South", "uksouth"},
+		{"  east   us  2 ", "eastus2"},
+		{"US West 2", "westus2"},
+		{"uksouth", "uksouth"},
+		{"eastus2", "eastus2"},
+		{"WestEurope", "westeurope"},
+		{"Contoso Region 9", "contosoregion9"},
+		{"contosoregion9", "contosoregion9"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.location, func(t *testing.T) {
+			if got := normaliseLocation(tt.location); got != tt.want {
+				t.Errorf("normaliseLocation(%q) = %q, want %q", tt.location, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	locksAPIVersion = "2016-09-01"
+
+	LockLevelCanNotDelete = "CanNotDelete"
+	LockLevelReadOnly     = "ReadOnly"
+
+	LockScopeServer        = "server"
+	LockScopeResourceGroup = "resource-group"
+	LockScopeSubscription  = "subscription"
+)
+
+// ManagementLock is a management lock that applies to a server.
+type ManagementLock struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Level string `json:"level"`
+	Notes string `json:"notes,omitempty"`
+	// Scope is where the lock is applied: server, resource-group or subscription, as locks are inherited.
+	Scope string `json:"scope"`
+}
+
+type armManagementLock struct {
+	ID         *string `json:"id"`
+	Name       *string `json:"name"`
+	Properties *struct {
+		Level *string `json:"level"`
+		Notes *string `json:"notes"`
+	} `json:"properties"`
+}
+
+// GetManagementLocks lists the management locks applying to a server, including those inherited from its resource
+// group and subscription. A server without locks returns an empty list.
+func (dp *AzureDataProcessor) GetManagementLocks(serverID string) ([]ManagementLock, error) {
+	client, err := dp.getARMClient()
+	if err != nil {
+		return nil, err
+	}
+
+	locks := make([]ManagementLock, 0)
+	path := fmt.Sprintf("%s/providers/Microsoft.Authorization/locks", serverID)
+	for lock, err := range ListARMResources[armManagementLock](dp.ctx, client, path, locksAPIVersion) {
+		if err != nil {
+			return nil, err
+		}
+
+		managementLock := ManagementLock{}
+		if lock.ID != nil {
+			managementLock.ID = *lock.ID
+			managementLock.Scope = lockScope(*lock.ID, serverID)
+		}
+		if lock.Name != nil {
+			managementLock.Name = *lock.Name
+		}
+		if lock.Properties != nil {
+			if lock.Properties.Level != nil {
+				managementLock.Level = *lock.Properties.Level
+			}
+			if lock.Properties.Notes != nil {
+				managementLock.Notes = *lock.Properties.Notes
+			}
+		}
+		locks = append(locks, managementLock)
+	}
+	return locks, nil
+}
+
+// lockScope classifies the scope a lock applies at from its ID, which is the ID of the locked resource, resource group
+// or subscription followed by /providers/Microsoft.Authorization/locks/<name>.
+func lockScope(lockID string, serverID string) string {
+	scope := lockID
+	if i := strings.Index(strings.ToLower(lockID), "/providers/microsoft.authorization/locks/"); i >= 0 {
+		scope = lockID[:i]
+	}
+	scope = strings.ToLower(strings.TrimSuffix(scope, "/"))
+	switch {
+	case scope == strings.ToLower(strings.TrimSuffix(serverID, "/")):
+		return LockScopeServer
+	case strings.Contains(scope, "/resourcegroups/"):
+		return LockScopeResourceGroup
+	default:
+		return LockScopeSubscription
+	}
+}
+
+// HasDeleteLock reports whether any of the locks prevents deletion.
+func HasDeleteLock(locks []ManagementLock) bool {
+	for _, lock := range locks {
+		if strings.EqualFold(lock.Level, LockLevelCanNotDelete) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+package internal
+
+import (
+	"strconv"
+	"strings"
+)
+
+// LoggingSettings groups the server parameters governing logging and Query Store in the policy input, parsed into
+// typed values. A field is omitted when its parameter wasn't collected, e.g. on server versions without it.
+type LoggingSettings struct {
+	// Statement is the log_statement setting: none, ddl, mod or all.
+	Statement string `json:"log_statement,omitempty"`
+	// MinDurationStatementMs logs statements running at least this long, where -1 disables it.
+	MinDurationStatementMs *int   `json:"log_min_duration_statement_ms,omitempty"`
+	Connections            *bool  `json:"log_connections,omitempty"`
+	Disconnections         *bool  `json:"log_disconnections,omitempty"`
+	Checkpoints            *bool  `json:"log_checkpoints,omitempty"`
+	LinePrefix             string `json:"log_line_prefix,omitempty"`
+	// RetentionDays is how long server logs are kept for download, from logfiles.retention_days.
+	RetentionDays *int `json:"retention_days,omitempty"`
+	// PGAuditLog is the pgaudit.log setting, the classes of statement audited by the pgaudit extension.
+	PGAuditLog string      `json:"pgaudit_log,omitempty"`
+	QueryStore *QueryStore `json:"query_store,omitempty"`
+}
+
+// QueryStore is the Query Store configuration, which is omitted on servers without Query Store parameters.
+type QueryStore struct {
+	// CaptureMode is none, top or all. Query Store is disabled when it is none.
+	CaptureMode string `json:"capture_mode,omitempty"`
+	Enabled     bool   `json:"enabled"`
+	// WaitSamplingCaptureMode is none or all, from pgms_wait_sampling.query_capture_mode.
+	WaitSamplingCaptureMode string `json:"wait_sampling_capture_mode,omitempty"`
+	RetentionDays           *int   `json:"retention_days,omitempty"`
+	StoreQueryPlans         *bool  `json:"store_query_plans,omitempty"`
+}
+
+// NewLoggingSettings groups the logging parameters from the server's parameters. It returns nil when no parameters
+// were collected.
+func NewLoggingSettings(configurations map[string]ServerConfiguration) *LoggingSettings {
+	if len(configurations) == 0 {
+		return nil
+	}
+
+	logging := &LoggingSettings{
+		Statement:              configurationString(configurations, "log_statement"),
+		MinDurationStatementMs: configurationInt(configurations, "log_min_duration_statement"),
+		Connections:            configurationBool(configurations, "log_connections"),
+		Disconnections:         configurationBool(configurations, "log_disconnections"),
+		Checkpoints:            configurationBool(configurations, "log_checkpoints"),
+		LinePrefix:             configurationString(configurations, "log_line_prefix"),
+		RetentionDays:          configurationInt(configurations, "logfiles.retention_days"),
+		PGAuditLog:             configurationString(configurations, "pgaudit.log"),
+	}
+
+	if _, ok := configurations["pg_qs.query_capture_mode"]; ok {
+		captureMode := configurationString(configurations, "pg_qs.query_capture_mode")
+		logging.QueryStore = &QueryStore{
+			CaptureMode:             captureMode,
+			Enabled:                 captureMode != "" && !strings.EqualFold(captureMode, "none"),
+			WaitSamplingCaptureMode: configurationString(configurations, "pgms_wait_sampling.query_capture_mode"),
+			RetentionDays:           configurationInt(configurations, "pg_qs.retention_period_in_days"),
+			StoreQueryPlans:         configurationBool(configurations, "pg_qs.store_query_plans"),
+		}
+	}
+	return logging
+}
+
+func configurationString(configurations map[string]ServerConfiguration, name string) string {
+	return configurations[name].Value
+}
+
+// configurationInt parses an integer parameter, returning nil when it is missing or not an integer.
+func configurationInt(configurations map[string]ServerConfiguration, name string) *int {
+	configuration, ok := configurations[name]
+	if !ok {
+		return nil
+	}
+	value, err := strconv.Atoi(strings.TrimSpace(configuration.Value))
+	if err != nil {
+		return nil
+	}
+	return &value
+}
+
+// configurationBool parses a boolean parameter, which PostgreSQL reports as on or off, returning nil when it is
+// missing or not a boolean.
+func configurationBool(configurations map[string]ServerConfiguration, name string) *bool {
+	configuration, ok := configurations[name]
+	if !ok {
+		return nil
+	}
+	switch strings.ToLower(strings.TrimSpace(configuration.Value)) {
+	case "on", "true":
+		return BoolAddressed(true)
+	case "off", "false":
+		return BoolAddressed(false)
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package internal
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/postgresql/armpostgresqlflexibleservers"
+)
+
+var weekdays = []string{"sunday", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday"}
+
+// MaintenanceSchedule is the approved set of days and start hours for custom maintenance windows.
+type MaintenanceSchedule struct {
+	days  map[int32]bool
+	hours map[int32]bool
+}
+
+// ParseMaintenanceSchedule reads the approved_maintenance_days and approved_maintenance_hours config. It returns
+// nil when neither is configured. Days are comma separated names (sunday, sun) or numbers where 0 is Sunday, as Azure
+// numbers them. Hours are comma separated hours or inclusive ranges such as 22-2, which wraps past midnight.
+func ParseMaintenanceSchedule(config map[string]string) (*MaintenanceSchedule, error) {
+	rawDays := strings.TrimSpace(config["approved_maintenance_days"])
+	rawHours := strings.TrimSpace(config["approved_maintenance_hours"])
+	if rawDays == "" && rawHours == "" {
+		return nil, nil
+	}
+
+	schedule := &MaintenanceSchedule{}
+
+	if rawDays != "" {
+		schedule.days = map[int32]bool{}
+		for _, day := range strings.Split(rawDays, ",") {
+			number, err := parseWeekday(strings.TrimSpace(day))
+			if err != nil {
+				return nil, fmt.Errorf("approved_maintenance_days: %w", err)
+			}
+			schedule.days[number] = true
+		}
+	}
+
+	if rawHours != "" {
+		schedule.hours = map[int32]bool{}
+		for _, hours := range strings.Split(rawHours, ",") {
+			if err := schedule.addHours(strings.TrimSpace(hours)); err != nil {
+				return nil, fmt.Errorf("approved_maintenance_hours: %w", err)
+			}
+		}
+	}
+
+	return schedule, nil
+}
+
+func parseWeekday(day string) (int32, error) {
+	if number, err := strconv.Atoi(day); err == nil {
+		if number < 0 || number > 6 {
+			return 0, fmt.Errorf("day %d is out of range, expected 0 (Sunday) to 6 (Saturday)", number)
+		}
+		return int32(number), nil
+	}
+
+	lower := strings.ToLower(day)
+	for number, name := range weekdays {
+		if lower == name || (len(lower) == 3 && strings.HasPrefix(name, lower)) {
+			return int32(number), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown day %q", day)
+}
+
+func (s *MaintenanceSchedule) addHours(hours string) error {
+	startRaw, endRaw, isRange := strings.Cut(hours, "-")
+	if !isRange {
+		endRaw = startRaw
+	}
+
+	start, err := parseHour(startRaw)
+	if err != nil {
+		return err
+	}
+	end, err := parseHour(endRaw)
+	if err != nil {
+		return err
+	}
+
+	for hour := start; ; hour = (hour + 1) % 24 {
+		s.hours[hour] = true
+		if hour == end {
+			return nil
+		}
+	}
+}
+
+func parseHour(hour string) (int32, error) {
+	number, err := strconv.Atoi(strings.TrimSpace(hour))
+	if err != nil || number < 0 || number > 23 {
+		return 0, fmt.Errorf("invalid hour %q, expected 0 to 23", hour)
+	}
+	return int32(number), nil
+}
+
+// IsCustomMaintenanceWindow reports whether the server has a custom maintenance window. A missing window is
+// system managed.
+func IsCustomMaintenanceWindow(window *armpostgresqlflexibleservers.MaintenanceWindow) bool {
+	return window != nil && window.CustomWindow != nil && strings.EqualFold(*window.CustomWindow, "Enabled")
+}
+
+const (
+	MaintenanceModeCustom        = "custom"
+	MaintenanceModeSystemManaged = "system-managed"
+)
+
+// ServerMaintenanceWindow is a server's maintenance window in the policy input. SystemManaged is true when the server
+// has no custom window, including when Azure omits the window entirely, in which case the schedule fields are omitted.
+type ServerMaintenanceWindow struct {
+	// Mode is custom or system-managed, matching the maintenance-window inventory prop.
+	Mode          string `json:"mode"`
+	SystemManaged bool   `json:"system_managed"`
+	DayOfWeek     *int32 `json:"day_of_week,omitempty"`
+	Day           string `json:"day,omitempty"`
+	StartHour     *int32 `json:"start_hour,omitempty"`
+	StartMinute   *int32 `json:"start_minute,omitempty"`
+}
+
+// NewServerMaintenanceWindow summarises the server's maintenance window, which may be nil.
+func NewServerMaintenanceWindow(window *armpostgresqlflexibleservers.MaintenanceWindow) *ServerMaintenanceWindow {
+	if !IsCustomMaintenanceWindow(window) {
+		return &ServerMaintenanceWindow{Mode: MaintenanceModeSystemManaged, SystemManaged: true}
+	}
+
+	result := &ServerMaintenanceWindow{
+		Mode:        MaintenanceModeCustom,
+		DayOfWeek:   window.DayOfWeek,
+		StartHour:   window.StartHour,
+		StartMinute: window.StartMinute,
+	}
+	if window.DayOfWeek != nil {
+		result.Day = describeWeekday(window.DayOfWeek)
+	}
+	return result
+}
+
+// Evaluate compares a server's maintenance window against the schedule, returning whether it complies and why not.
+func (s *MaintenanceSchedule) Evaluate(window *armpostgresqlflexibleservers.MaintenanceWindow, allowSystemManaged bool) (bool, string) {
+	if !IsCustomMaintenanceWindow(window) {
+		if allowSystemManaged {
+			return true, ""
+		}
+		return false, "the maintenance window is system managed rather than a custom window"
+	}
+
+	if s.days != nil && (window.DayOfWeek == nil || !s.days[*window.DayOfWeek]) {
+		return false, fmt.Sprintf("maintenance starts on %s, which is not an approved day", describeWeekday(window.DayOfWeek))
+	}
+	if s.hours != nil && (window.StartHour == nil || !s.hours[*window.StartHour]) {
+		return false, fmt.Sprintf("maintenance starts at hour %s, which is not an approved hour", describeInt32(window.StartHour))
+	}
+	return true, ""
+}
+
+func describeWeekday(day *int32) string {
+	if day == nil || *day < 0 || int(*day) >= len(weekdays) {
+		return "an unknown day"
+	}
+	return weekdays[*day]
+}
+
+func describeInt32(value *int32) string {
+	if value == nil {
+		return "unknown"
+	}
+	return strconv.Itoa(int(*value))
+}
@@ -0,0 +1,117 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/postgresql/armpostgresqlflexibleservers"
+)
+
+func TestMaintenanceScheduleEvaluate(t *testing.T) {
+	schedule, err := ParseMaintenanceSchedule(map[string]string{
+		"approved_maintenance_days":  "sat, Sunday",
+		"approved_maintenance_hours": "22-2, 12",
+	})
+	if err != nil {
+		t.Fatalf("ParseMaintenanceSchedule: %v", err)
+	}
+
+	custom := func(day int32, hour int32) *armpostgresqlflexibleservers.MaintenanceWindow {
+		return &armpostgresqlflexibleservers.MaintenanceWindow{
+			CustomWindow: to.Ptr("Enabled"),
+			DayOfWeek:    to.Ptr(day),
+			StartHour:    to.Ptr(hour),
+			StartMinute:  to.Ptr(int32(0)),
+		}
+	}
+
+	tests := []struct {
+		name               string
+		window             *armpostgresqlflexibleservers.MaintenanceWindow
+		allowSystemManaged bool
+		want               bool
+	}{
+		{"nil window allowed", nil, true, true},
+		{"nil window not allowed", nil, false, false},
+		{"system managed allowed", &armpostgresqlflexibleservers.MaintenanceWindow{CustomWindow: to.Ptr("Disabled")}, true, true},
+		{"system managed not allowed", &armpostgresqlflexibleservers.MaintenanceWindow{CustomWindow: to.Ptr("Disabled")}, false, false},
+		{"approved day and hour", custom(6, 23), false, true},
+		{"approved hour after midnight", custom(0, 2), false, true},
+		{"approved single hour", custom(0, 12), false, true},
+		{"unapproved day", custom(3, 23), true, false},
+		{"unapproved hour", custom(6, 3), true, false},
+		{"unapproved hour before the range", custom(6, 21), true, false},
+		{"missing day", &armpostgresqlflexibleservers.MaintenanceWindow{CustomWindow: to.Ptr("Enabled"), StartHour: to.Ptr(int32(23))}, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, reason := schedule.Evaluate(tt.window, tt.allowSystemManaged)
+			if got != tt.want {
+				t.Errorf("Evaluate() = %v (%q), want %v", got, reason, tt.want)
+			}
+			if !got && reason == "" {
+				t.Errorf("Evaluate() gave no reason for a non-compliant window")
+			}
+		})
+	}
+}
+
+func TestMaintenanceScheduleAddHours(t *testing.T) {
+	tests := []struct {
+		hours   string
+		want    []int32
+		wantErr bool
+	}{
+		{hours: "3", want: []int32{3}},
+		{hours: "1-3", want: []int32{1, 2, 3}},
+		{hours: " 23 - 1 ", want: []int32{23, 0, 1}},
+		{hours: "0-23", want: []int32{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23}},
+		{hours: "24", wantErr: true},
+		{hours: "-1", wantErr: true},
+		{hours: "1-", wantErr: true},
+		{hours: "1-24", wantErr: true},
+		{hours: "night", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.hours, func(t *testing.T) {
+			schedule := &MaintenanceSchedule{hours: map[int32]bool{}}
+			err := schedule.addHours(tt.hours)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("addHours(%q) added %v, want an error", tt.hours, schedule.hours)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("addHours(%q): %v", tt.hours, err)
+			}
+
+			if len(schedule.hours) != len(tt.want) {
+				t.Errorf("addHours(%q) added %v, want %v", tt.hours, schedule.hours, tt.want)
+			}
+			for _, hour := range tt.want {
+				if !schedule.hours[hour] {
+					t.Errorf("addHours(%q) didn't add hour %d", tt.hours, hour)
+				}
+			}
+		})
+	}
+}
+
+func TestParseMaintenanceScheduleInvalid(t *testing.T) {
+	for _, config := range []map[string]string{
+		{"approved_maintenance_days": "someday"},
+		{"approved_maintenance_days": "7"},
+		{"approved_maintenance_hours": "22-25"},
+	} {
+		if schedule, err := ParseMaintenanceSchedule(config); err == nil {
+			t.Errorf("ParseMaintenanceSchedule(%v) = %+v, want an error", config, schedule)
+		}
+	}
+
+	if schedule, err := ParseMaintenanceSchedule(map[string]string{}); schedule != nil || err != nil {
+		t.Errorf("ParseMaintenanceSchedule(empty) = (%+v, %v), want (nil, nil)", schedule, err)
+	}
+}
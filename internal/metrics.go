@@ -0,0 +1,71 @@
+package internal
+
+import (
+	"maps"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+// runMetrics counts the servers a run handled and the time spent in each phase, summed across workers.
+// It is safe for concurrent use.
+type runMetrics struct {
+	start time.Time
+
+	listed    atomic.Int64
+	collected atomic.Int64
+	evaluated atomic.Int64
+	// skipped counts the servers excluded by the name and tag filters.
+	skipped atomic.Int64
+	// submitted counts the evidence written to every sink, so evidence that any sink failed to write isn't counted.
+	submitted atomic.Int64
+	// submittedBySink counts the evidence each sink wrote, keyed by sink name.
+	submittedMu     sync.Mutex
+	submittedBySink map[string]int64
+
+	listing    atomic.Int64
+	collection atomic.Int64
+	evaluation atomic.Int64
+	submission atomic.Int64
+}
+
+func newRunMetrics() *runMetrics {
+	return &runMetrics{start: time.Now(), submittedBySink: map[string]int64{}}
+}
+
+func (m *runMetrics) recordSubmitted(sink string, count int) {
+	m.submittedMu.Lock()
+	defer m.submittedMu.Unlock()
+	m.submittedBySink[sink] += int64(count)
+}
+
+// submittedBySinks returns a copy of the evidence counts by sink.
+func (m *runMetrics) submittedBySinks() map[string]int64 {
+	m.submittedMu.Lock()
+	defer m.submittedMu.Unlock()
+	return maps.Clone(m.submittedBySink)
+}
+
+// observe adds the time since start to the phase, returning it.
+func observe(phase *atomic.Int64, start time.Time) time.Duration {
+	elapsed := time.Since(start)
+	phase.Add(int64(elapsed))
+	return elapsed
+}
+
+// log writes the run's counts and phase durations. Collection, evaluation and submission are summed across
+// workers, so with concurrency above 1 they can exceed the run's total duration.
+func (m *runMetrics) log(logger hclog.Logger) {
+	logger.Info("Azure PostgreSQL run complete",
+		"servers_listed", m.listed.Load(),
+		"servers_collected", m.collected.Load(),
+		"servers_evaluated", m.evaluated.Load(),
+		"duration", time.Since(m.start),
+		"listing_duration", time.Duration(m.listing.Load()),
+		"collection_duration", time.Duration(m.collection.Load()),
+		"evaluation_duration", time.Duration(m.evaluation.Load()),
+		"submission_duration", time.Duration(m.submission.Load()),
+	)
+}
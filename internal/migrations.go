@@ -0,0 +1,108 @@
+package internal
+
+import (
+	"strings"
+	"time"
+)
+
+// defaultStalledMigrationDays is how long a migration may stay in progress before it is considered stalled, unless
+// stalled_migration_days is configured.
+const defaultStalledMigrationDays = 7
+
+// Migration is a migration into the server from a single server or another PostgreSQL source.
+type Migration struct {
+	Name string `json:"name"`
+	// State is e.g. InProgress, WaitingForUserAction, Succeeded, Failed, Canceled or ValidationFailed.
+	State    string `json:"state,omitempty"`
+	SubState string `json:"sub_state,omitempty"`
+	Error    string `json:"error,omitempty"`
+	// Mode is Offline or Online.
+	Mode string `json:"mode,omitempty"`
+	// SourceType is e.g. PostgreSQLSingleServer, OnPremises, AWS_RDS or GCP_CloudSQL.
+	SourceType         string     `json:"source_type,omitempty"`
+	SourceServerID     string     `json:"source_server_id,omitempty"`
+	DatabasesToMigrate []string   `json:"databases_to_migrate"`
+	WindowStart        *time.Time `json:"window_start,omitempty"`
+	WindowEnd          *time.Time `json:"window_end,omitempty"`
+	// Stalled is true for a migration still in progress stalled_migration_days after its window started.
+	Stalled bool `json:"stalled"`
+}
+
+type armMigration struct {
+	Name       *string `json:"name"`
+	Properties *struct {
+		CurrentStatus *struct {
+			State                  *string `json:"state"`
+			Error                  *string `json:"error"`
+			CurrentSubStateDetails *struct {
+				CurrentSubState *string `json:"currentSubState"`
+			} `json:"currentSubStateDetails"`
+		} `json:"currentStatus"`
+		MigrationMode                 *string    `json:"migrationMode"`
+		SourceType                    *string    `json:"sourceType"`
+		SourceDbServerResourceID      *string    `json:"sourceDbServerResourceId"`
+		DbsToMigrate                  []*string  `json:"dbsToMigrate"`
+		MigrationWindowStartTimeInUtc *time.Time `json:"migrationWindowStartTimeInUtc"`
+		MigrationWindowEndTimeInUtc   *time.Time `json:"migrationWindowEndTimeInUtc"`
+	} `json:"properties"`
+}
+
+// GetMigrations lists the migrations into a server, both in progress and finished. A migration is stalled when it is
+// still in progress stalledAfter its window started.
+func (dp *AzureDataProcessor) GetMigrations(serverID string, stalledAfter time.Duration) ([]Migration, error) {
+	client, err := dp.getARMClient()
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := make([]Migration, 0)
+	for migration, err := range ListARMResources[armMigration](dp.ctx, client, serverID+"/migrations", flexibleServersAPIVersion) {
+		if err != nil {
+			return nil, err
+		}
+
+		m := Migration{
+			DatabasesToMigrate: make([]string, 0),
+		}
+		if migration.Name != nil {
+			m.Name = *migration.Name
+		}
+		if properties := migration.Properties; properties != nil {
+			if status := properties.CurrentStatus; status != nil {
+				if status.State != nil {
+					m.State = *status.State
+				}
+				if status.Error != nil {
+					m.Error = *status.Error
+				}
+				if status.CurrentSubStateDetails != nil && status.CurrentSubStateDetails.CurrentSubState != nil {
+					m.SubState = *status.CurrentSubStateDetails.CurrentSubState
+				}
+			}
+			if properties.MigrationMode != nil {
+				m.Mode = *properties.MigrationMode
+			}
+			if properties.SourceType != nil {
+				m.SourceType = *properties.SourceType
+			}
+			if properties.SourceDbServerResourceID != nil {
+				m.SourceServerID = *properties.SourceDbServerResourceID
+			}
+			for _, database := range properties.DbsToMigrate {
+				if database != nil {
+					m.DatabasesToMigrate = append(m.DatabasesToMigrate, *database)
+				}
+			}
+			m.WindowStart = properties.MigrationWindowStartTimeInUtc
+			m.WindowEnd = properties.MigrationWindowEndTimeInUtc
+		}
+		m.Stalled = m.InProgress() && m.WindowStart != nil && time.Since(*m.WindowStart) > stalledAfter
+		migrations = append(migrations, m)
+	}
+	return migrations, nil
+}
+
+// InProgress reports whether the migration hasn't finished, including while it waits for the user to cut over.
+func (m Migration) InProgress() bool {
+	return strings.EqualFold(m.State, "InProgress") || strings.EqualFold(m.State, "WaitingForUserAction") || strings.EqualFold(m.State, "CleaningUp")
+}
@@ -0,0 +1,67 @@
+package internal
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// NameFilter matches servers on their names using shell style glob patterns, as understood by path.Match, e.g.
+// prod-* or db-??. Names are matched case-insensitively, as Azure server names are always lower case.
+type NameFilter struct {
+	include []string
+	exclude []string
+}
+
+// ParseNameFilter parses comma separated include and exclude glob patterns. A server matches when it matches any
+// include pattern, or there are none, and no exclude pattern. Empty patterns filter nothing.
+func ParseNameFilter(include string, exclude string) (*NameFilter, error) {
+	filter := &NameFilter{}
+
+	var err error
+	if filter.include, err = parseNamePatterns(include); err != nil {
+		return nil, fmt.Errorf("server_name_include: %w", err)
+	}
+	if filter.exclude, err = parseNamePatterns(exclude); err != nil {
+		return nil, fmt.Errorf("server_name_exclude: %w", err)
+	}
+	return filter, nil
+}
+
+func parseNamePatterns(patterns string) ([]string, error) {
+	parsed := make([]string, 0)
+	for _, pattern := range strings.Split(patterns, ",") {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if pattern == "" {
+			continue
+		}
+		// path.Match checks the whole pattern even when the name doesn't match, so this validates it up front.
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		if strings.Contains(pattern, "/") {
+			return nil, fmt.Errorf("invalid pattern %q: server names can't contain /", pattern)
+		}
+		parsed = append(parsed, pattern)
+	}
+	return parsed, nil
+}
+
+// Matches reports whether the server name is included and not excluded.
+func (f *NameFilter) Matches(name string) bool {
+	name = strings.ToLower(name)
+	if len(f.include) > 0 && !matchesAnyPattern(f.include, name) {
+		return false
+	}
+	return !matchesAnyPattern(f.exclude, name)
+}
+
+func matchesAnyPattern(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		// The patterns were validated when parsed, so matching can't fail.
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,322 @@
+package internal
+
+import (
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/postgresql/armpostgresqlflexibleservers"
+)
+
+// networkUnknown is reported for a public network access setting the server doesn't report.
+const networkUnknown = "unknown"
+
+// NetworkConfig is a server's network configuration in the policy input.
+type NetworkConfig struct {
+	// PublicNetworkAccess is Enabled, Disabled or unknown.
+	PublicNetworkAccess string `json:"public_network_access"`
+	// VNetIntegrated is true when the server is injected into a delegated subnet, in which case it has no firewall rules.
+	VNetIntegrated            bool    `json:"vnet_integrated"`
+	DelegatedSubnetResourceID *string `json:"delegated_subnet_resource_id,omitempty"`
+	PrivateDNSZoneResourceID  *string `json:"private_dns_zone_resource_id,omitempty"`
+	// PrivateEndpoints is always present, so policies see an empty list for servers without private endpoints.
+	PrivateEndpoints []PrivateEndpoint `json:"private_endpoints"`
+	// Subnet and PrivateDNSZone are resolved for VNet integrated servers.
+	Subnet         *Subnet         `json:"subnet,omitempty"`
+	PrivateDNSZone *PrivateDNSZone `json:"private_dns_zone,omitempty"`
+}
+
+const (
+	subnetsAPIVersion         = "2023-09-01"
+	privateDNSZonesAPIVersion = "2020-06-01"
+)
+
+// Subnet is the delegated subnet a VNet integrated server is injected into.
+type Subnet struct {
+	ID               string `json:"id"`
+	VirtualNetworkID string `json:"virtual_network_id"`
+	AddressPrefix    string `json:"address_prefix,omitempty"`
+	// Delegations are the service names the subnet is delegated to, e.g. Microsoft.DBforPostgreSQL/flexibleServers.
+	Delegations            []string `json:"delegations"`
+	NetworkSecurityGroupID string   `json:"network_security_group_id,omitempty"`
+}
+
+// PrivateDNSZone is the private DNS zone a VNet integrated server registers its name in.
+type PrivateDNSZone struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// VirtualNetworkLinks are the virtual networks that resolve names in the zone. They are omitted for a zone
+	// without links, and when they couldn't be listed.
+	VirtualNetworkLinks []VirtualNetworkLink `json:"virtual_network_links,omitempty"`
+}
+
+// VirtualNetworkLink links a private DNS zone to a virtual network, so resources in the network resolve its names.
+type VirtualNetworkLink struct {
+	Name             string `json:"name"`
+	VirtualNetworkID string `json:"virtual_network_id,omitempty"`
+	// RegistrationEnabled is true when virtual machines in the network register their own records in the zone.
+	RegistrationEnabled bool `json:"registration_enabled"`
+	// State is Completed once the link is in effect, or InProgress.
+	State             string `json:"state,omitempty"`
+	ProvisioningState string `json:"provisioning_state,omitempty"`
+}
+
+type armSubnet struct {
+	ID         *string `json:"id"`
+	Properties *struct {
+		AddressPrefix   *string   `json:"addressPrefix"`
+		AddressPrefixes []*string `json:"addressPrefixes"`
+		Delegations     []struct {
+			Properties *struct {
+				ServiceName *string `json:"serviceName"`
+			} `json:"properties"`
+		} `json:"delegations"`
+		NetworkSecurityGroup *struct {
+			ID *string `json:"id"`
+		} `json:"networkSecurityGroup"`
+	} `json:"properties"`
+}
+
+type armPrivateDNSZone struct {
+	ID   *string `json:"id"`
+	Name *string `json:"name"`
+}
+
+type armVirtualNetworkLink struct {
+	Name       *string `json:"name"`
+	Properties *struct {
+		VirtualNetwork *struct {
+			ID *string `json:"id"`
+		} `json:"virtualNetwork"`
+		RegistrationEnabled     *bool   `json:"registrationEnabled"`
+		VirtualNetworkLinkState *string `json:"virtualNetworkLinkState"`
+		ProvisioningState       *string `json:"provisioningState"`
+	} `json:"properties"`
+}
+
+// GetSubnet resolves a delegated subnet by its resource ID.
+func (dp *AzureDataProcessor) GetSubnet(subnetID string) (*Subnet, error) {
+	client, err := dp.getARMClient()
+	if err != nil {
+		return nil, err
+	}
+
+	resource := &armSubnet{}
+	if err := client.Get(dp.ctx, subnetID, subnetsAPIVersion, resource); err != nil {
+		return nil, err
+	}
+
+	subnet := &Subnet{
+		ID:          subnetID,
+		Delegations: make([]string, 0),
+	}
+	// A subnet ID is its virtual network's ID followed by /subnets/<name>.
+	if i := strings.LastIndex(strings.ToLower(subnetID), "/subnets/"); i >= 0 {
+		subnet.VirtualNetworkID = subnetID[:i]
+	}
+	if properties := resource.Properties; properties != nil {
+		if properties.AddressPrefix != nil {
+			subnet.AddressPrefix = *properties.AddressPrefix
+		} else if len(properties.AddressPrefixes) > 0 && properties.AddressPrefixes[0] != nil {
+			subnet.AddressPrefix = *properties.AddressPrefixes[0]
+		}
+		for _, delegation := range properties.Delegations {
+			if delegation.Properties != nil && delegation.Properties.ServiceName != nil {
+				subnet.Delegations = append(subnet.Delegations, *delegation.Properties.ServiceName)
+			}
+		}
+		if properties.NetworkSecurityGroup != nil && properties.NetworkSecurityGroup.ID != nil {
+			subnet.NetworkSecurityGroupID = *properties.NetworkSecurityGroup.ID
+		}
+	}
+	return subnet, nil
+}
+
+// GetPrivateDNSZone resolves a private DNS zone by its resource ID.
+func (dp *AzureDataProcessor) GetPrivateDNSZone(zoneID string) (*PrivateDNSZone, error) {
+	client, err := dp.getARMClient()
+	if err != nil {
+		return nil, err
+	}
+
+	resource := &armPrivateDNSZone{}
+	if err := client.Get(dp.ctx, zoneID, privateDNSZonesAPIVersion, resource); err != nil {
+		return nil, err
+	}
+
+	zone := &PrivateDNSZone{ID: zoneID}
+	if resource.Name != nil {
+		zone.Name = *resource.Name
+	}
+	return zone, nil
+}
+
+// GetVirtualNetworkLinks lists the virtual network links of a private DNS zone.
+func (dp *AzureDataProcessor) GetVirtualNetworkLinks(zoneID string) ([]VirtualNetworkLink, error) {
+	client, err := dp.getARMClient()
+	if err != nil {
+		return nil, err
+	}
+
+	links := make([]VirtualNetworkLink, 0)
+	for link, err := range ListARMResources[armVirtualNetworkLink](dp.ctx, client, zoneID+"/virtualNetworkLinks", privateDNSZonesAPIVersion) {
+		if err != nil {
+			return nil, err
+		}
+
+		l := VirtualNetworkLink{}
+		if link.Name != nil {
+			l.Name = *link.Name
+		}
+		if properties := link.Properties; properties != nil {
+			if properties.VirtualNetwork != nil && properties.VirtualNetwork.ID != nil {
+				l.VirtualNetworkID = *properties.VirtualNetwork.ID
+			}
+			if properties.RegistrationEnabled != nil {
+				l.RegistrationEnabled = *properties.RegistrationEnabled
+			}
+			if properties.VirtualNetworkLinkState != nil {
+				l.State = *properties.VirtualNetworkLinkState
+			}
+			if properties.ProvisioningState != nil {
+				l.ProvisioningState = *properties.ProvisioningState
+			}
+		}
+		links = append(links, l)
+	}
+	return links, nil
+}
+
+// LinksVirtualNetwork reports whether the zone has a completed link to the virtual network. Resource IDs are
+// compared case-insensitively.
+func (z *PrivateDNSZone) LinksVirtualNetwork(virtualNetworkID string) bool {
+	for _, link := range z.VirtualNetworkLinks {
+		if strings.EqualFold(link.VirtualNetworkID, virtualNetworkID) && strings.EqualFold(link.State, "Completed") {
+			return true
+		}
+	}
+	return false
+}
+
+// PrivateEndpoint is a private endpoint connection to the server.
+type PrivateEndpoint struct {
+	Name       string `json:"name"`
+	EndpointID string `json:"endpoint_id,omitempty"`
+	// Status is the connection state, e.g. Approved, Pending or Rejected.
+	Status string `json:"status,omitempty"`
+	// Description is the reason given when the connection was approved or rejected.
+	Description       string `json:"description,omitempty"`
+	ProvisioningState string `json:"provisioning_state,omitempty"`
+}
+
+// NewNetworkConfig summarises the server's network configuration. The extended server may be nil when it could
+// not be fetched, in which case private endpoints are not known and the SDK's network settings are used.
+func NewNetworkConfig(server *armpostgresqlflexibleservers.Server, extended *ExtendedServer) *NetworkConfig {
+	network := &NetworkConfig{
+		PublicNetworkAccess: networkUnknown,
+		PrivateEndpoints:    make([]PrivateEndpoint, 0),
+	}
+
+	if server.Properties != nil && server.Properties.Network != nil {
+		sdkNetwork := server.Properties.Network
+		if sdkNetwork.PublicNetworkAccess != nil {
+			network.PublicNetworkAccess = string(*sdkNetwork.PublicNetworkAccess)
+		}
+		if sdkNetwork.DelegatedSubnetResourceID != nil && *sdkNetwork.DelegatedSubnetResourceID != "" {
+			network.DelegatedSubnetResourceID = sdkNetwork.DelegatedSubnetResourceID
+			network.VNetIntegrated = true
+		}
+		if sdkNetwork.PrivateDNSZoneArmResourceID != nil && *sdkNetwork.PrivateDNSZoneArmResourceID != "" {
+			network.PrivateDNSZoneResourceID = sdkNetwork.PrivateDNSZoneArmResourceID
+		}
+	}
+
+	if extended == nil || extended.Properties == nil {
+		return network
+	}
+	if extended.Properties.Network != nil && extended.Properties.Network.PublicNetworkAccess != nil {
+		network.PublicNetworkAccess = *extended.Properties.Network.PublicNetworkAccess
+	}
+	for _, connection := range extended.Properties.PrivateEndpointConnections {
+		endpoint := PrivateEndpoint{}
+		if connection.Name != nil {
+			endpoint.Name = *connection.Name
+		}
+		if properties := connection.Properties; properties != nil {
+			if properties.PrivateEndpoint != nil && properties.PrivateEndpoint.ID != nil {
+				endpoint.EndpointID = *properties.PrivateEndpoint.ID
+			}
+			if state := properties.PrivateLinkServiceConnectionState; state != nil {
+				if state.Status != nil {
+					endpoint.Status = *state.Status
+				}
+				if state.Description != nil {
+					endpoint.Description = *state.Description
+				}
+			}
+			if properties.ProvisioningState != nil {
+				endpoint.ProvisioningState = *properties.ProvisioningState
+			}
+		}
+		network.PrivateEndpoints = append(network.PrivateEndpoints, endpoint)
+	}
+	return network
+}
+
+// NewSingleServerNetworkConfig summarises a single server's network configuration. Single servers can't be
+// VNet integrated, and their private endpoints aren't collected.
+func NewSingleServerNetworkConfig(properties *SingleServerProperties) *NetworkConfig {
+	network := &NetworkConfig{
+		PublicNetworkAccess: networkUnknown,
+		PrivateEndpoints:    make([]PrivateEndpoint, 0),
+	}
+	if properties != nil && properties.PublicNetworkAccess != nil {
+		network.PublicNetworkAccess = *properties.PublicNetworkAccess
+	}
+	return network
+}
+
+// PublicNetworkAccessEnabled reports whether public network access is known, and if so whether it is enabled.
+func (n *NetworkConfig) PublicNetworkAccessEnabled() (bool, bool) {
+	if n.PublicNetworkAccess == networkUnknown {
+		return false, false
+	}
+	return strings.EqualFold(n.PublicNetworkAccess, "Enabled"), true
+}
+
+// AllPrivateEndpointsApproved reports whether every private endpoint connection has been approved. It is true for a
+// server without private endpoints.
+func (n *NetworkConfig) AllPrivateEndpointsApproved() bool {
+	for _, endpoint := range n.PrivateEndpoints {
+		if !strings.EqualFold(endpoint.Status, "Approved") {
+			return false
+		}
+	}
+	return true
+}
+
+// HasApprovedPrivateEndpoint reports whether any private endpoint connection has been approved.
+func (n *NetworkConfig) HasApprovedPrivateEndpoint() bool {
+	for _, endpoint := range n.PrivateEndpoints {
+		if strings.EqualFold(endpoint.Status, "Approved") {
+			return true
+		}
+	}
+	return false
+}
+
+// PrivateAccessOnly reports whether it is known that the server is only reachable privately, and if so whether it
+// is: public network access is disabled, and the server is VNet integrated or has an approved private endpoint.
+// A server with public access disabled whose private endpoints weren't collected is not known either way.
+func (n *NetworkConfig) PrivateAccessOnly(privateEndpointsKnown bool) (bool, bool) {
+	publicEnabled, known := n.PublicNetworkAccessEnabled()
+	switch {
+	case n.VNetIntegrated:
+		return true, true
+	case !known:
+		return false, false
+	case publicEnabled:
+		return false, true
+	case !privateEndpointsKnown:
+		return false, false
+	}
+	return n.HasApprovedPrivateEndpoint(), true
+}
@@ -0,0 +1,106 @@
+package internal
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+const policyInsightsAPIVersion = "2019-10-01"
+
+// PolicyState is the latest Azure Policy compliance result for a server against one policy definition.
+type PolicyState struct {
+	PolicyAssignmentID   string `json:"policy_assignment_id"`
+	PolicyAssignmentName string `json:"policy_assignment_name,omitempty"`
+	PolicyDefinitionID   string `json:"policy_definition_id"`
+	PolicyDefinitionName string `json:"policy_definition_name,omitempty"`
+	// PolicySetDefinitionID is the initiative the definition was assigned through, if any.
+	PolicySetDefinitionID string `json:"policy_set_definition_id,omitempty"`
+	// Effect is the policy definition's effect, e.g. audit, deny or auditIfNotExists.
+	Effect string `json:"effect,omitempty"`
+	// ComplianceState is Compliant, NonCompliant, Exempt or Unknown.
+	ComplianceState string     `json:"compliance_state"`
+	Timestamp       *time.Time `json:"timestamp,omitempty"`
+}
+
+type armPolicyState struct {
+	PolicyAssignmentID     *string    `json:"policyAssignmentId"`
+	PolicyAssignmentName   *string    `json:"policyAssignmentName"`
+	PolicyDefinitionID     *string    `json:"policyDefinitionId"`
+	PolicyDefinitionName   *string    `json:"policyDefinitionName"`
+	PolicySetDefinitionID  *string    `json:"policySetDefinitionId"`
+	PolicyDefinitionAction *string    `json:"policyDefinitionAction"`
+	ComplianceState        *string    `json:"complianceState"`
+	Timestamp              *time.Time `json:"timestamp"`
+}
+
+type armPolicyStatesPage struct {
+	Value    []armPolicyState `json:"value"`
+	NextLink *string          `json:"@odata.nextLink,omitempty"`
+}
+
+// GetPolicyStates queries Policy Insights for the latest compliance state of a server against every policy assigned
+// to it. A server no policy applies to returns an empty list.
+func (dp *AzureDataProcessor) GetPolicyStates(serverID string) ([]PolicyState, error) {
+	client, err := dp.getARMClient()
+	if err != nil {
+		return nil, err
+	}
+
+	states := make([]PolicyState, 0)
+	page := &armPolicyStatesPage{}
+	if err := client.Post(dp.ctx, serverID+"/providers/Microsoft.PolicyInsights/policyStates/latest/queryResults", policyInsightsAPIVersion, page); err != nil {
+		return nil, err
+	}
+	for {
+		for _, state := range page.Value {
+			states = append(states, newPolicyState(state))
+		}
+		if page.NextLink == nil || *page.NextLink == "" {
+			return states, nil
+		}
+		// The next link already carries the api-version and any continuation token.
+		nextLink := *page.NextLink
+		page = &armPolicyStatesPage{}
+		if err := client.do(dp.ctx, http.MethodPost, nextLink, "", page); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func newPolicyState(state armPolicyState) PolicyState {
+	s := PolicyState{}
+	if state.PolicyAssignmentID != nil {
+		s.PolicyAssignmentID = *state.PolicyAssignmentID
+	}
+	if state.PolicyAssignmentName != nil {
+		s.PolicyAssignmentName = *state.PolicyAssignmentName
+	}
+	if state.PolicyDefinitionID != nil {
+		s.PolicyDefinitionID = *state.PolicyDefinitionID
+	}
+	if state.PolicyDefinitionName != nil {
+		s.PolicyDefinitionName = *state.PolicyDefinitionName
+	}
+	if state.PolicySetDefinitionID != nil {
+		s.PolicySetDefinitionID = *state.PolicySetDefinitionID
+	}
+	if state.PolicyDefinitionAction != nil {
+		s.Effect = *state.PolicyDefinitionAction
+	}
+	if state.ComplianceState != nil {
+		s.ComplianceState = *state.ComplianceState
+	}
+	s.Timestamp = state.Timestamp
+	return s
+}
+
+// HasNonCompliantPolicyState reports whether Azure Policy finds the server non-compliant with any assigned policy.
+func HasNonCompliantPolicyState(states []PolicyState) bool {
+	for _, state := range states {
+		if strings.EqualFold(state.ComplianceState, "NonCompliant") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,43 @@
+package internal
+
+// ConnectionPooling is the built-in PgBouncer configuration, from the pgbouncer.* server parameters, parsed into
+// typed values. A field is omitted when its parameter wasn't collected.
+type ConnectionPooling struct {
+	// Enabled is from pgbouncer.enabled. PgBouncer listens on port 6432 when it is enabled.
+	Enabled *bool `json:"enabled,omitempty"`
+	// PoolMode is session, transaction or statement.
+	PoolMode        string `json:"pool_mode,omitempty"`
+	DefaultPoolSize *int   `json:"default_pool_size,omitempty"`
+	MinPoolSize     *int   `json:"min_pool_size,omitempty"`
+	MaxClientConn   *int   `json:"max_client_conn,omitempty"`
+	// MaxPreparedStatements is how many prepared statements PgBouncer tracks per connection, where 0 disables
+	// prepared statement support in transaction mode.
+	MaxPreparedStatements    *int `json:"max_prepared_statements,omitempty"`
+	QueryWaitTimeoutSeconds  *int `json:"query_wait_timeout_seconds,omitempty"`
+	ServerIdleTimeoutSeconds *int `json:"server_idle_timeout_seconds,omitempty"`
+	// IgnoreStartupParameters are the comma separated startup parameters PgBouncer ignores rather than rejecting.
+	IgnoreStartupParameters string `json:"ignore_startup_parameters,omitempty"`
+	// StatsUsers are the comma separated users allowed to read PgBouncer's statistics.
+	StatsUsers string `json:"stats_users,omitempty"`
+}
+
+// NewConnectionPooling groups the PgBouncer parameters from the server's parameters. It returns nil on servers
+// without them, such as Burstable servers, which don't offer PgBouncer.
+func NewConnectionPooling(configurations map[string]ServerConfiguration) *ConnectionPooling {
+	if _, ok := configurations["pgbouncer.enabled"]; !ok {
+		return nil
+	}
+
+	return &ConnectionPooling{
+		Enabled:                  configurationBool(configurations, "pgbouncer.enabled"),
+		PoolMode:                 configurationString(configurations, "pgbouncer.pool_mode"),
+		DefaultPoolSize:          configurationInt(configurations, "pgbouncer.default_pool_size"),
+		MinPoolSize:              configurationInt(configurations, "pgbouncer.min_pool_size"),
+		MaxClientConn:            configurationInt(configurations, "pgbouncer.max_client_conn"),
+		MaxPreparedStatements:    configurationInt(configurations, "pgbouncer.max_prepared_statements"),
+		QueryWaitTimeoutSeconds:  configurationInt(configurations, "pgbouncer.query_wait_timeout"),
+		ServerIdleTimeoutSeconds: configurationInt(configurations, "pgbouncer.server_idle_timeout"),
+		IgnoreStartupParameters:  configurationString(configurations, "pgbouncer.ignore_startup_parameters"),
+		StatsUsers:               configurationString(configurations, "pgbouncer.stats_users"),
+	}
+}
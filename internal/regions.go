@@ -0,0 +1,88 @@
+package internal
+
+// Region is Azure's metadata for a region, used to check where a server's replicas are placed.
+type Region struct {
+	Name string `json:"name"`
+	// Geography is e.g. Europe or United States, and GeographyGroup e.g. Europe or US.
+	Geography      string `json:"geography,omitempty"`
+	GeographyGroup string `json:"geography_group,omitempty"`
+	// PairedRegions are the regions Azure pairs with this one for disaster recovery, e.g. northeurope for westeurope.
+	PairedRegions []string `json:"paired_regions"`
+}
+
+type armLocation struct {
+	Name     *string `json:"name"`
+	Metadata *struct {
+		Geography      *string `json:"geography"`
+		GeographyGroup *string `json:"geographyGroup"`
+		PairedRegion   []struct {
+			Name *string `json:"name"`
+		} `json:"pairedRegion"`
+	} `json:"metadata"`
+}
+
+type regionsResult struct {
+	regions map[string]*Region
+	err     error
+}
+
+// GetRegion looks a region up in the regions available to a subscription. The regions are listed once per
+// subscription, and the result, including failures, is cached for the rest of the run. A region the subscription
+// doesn't list returns nil.
+func (dp *AzureDataProcessor) GetRegion(subscriptionID string, location string) (*Region, error) {
+	dp.regionsMu.Lock()
+	defer dp.regionsMu.Unlock()
+
+	result, ok := dp.regions[subscriptionID]
+	if !ok {
+		regions, err := dp.listRegions(subscriptionID)
+		result = regionsResult{regions: regions, err: err}
+		dp.regions[subscriptionID] = result
+	}
+	if result.err != nil {
+		return nil, result.err
+	}
+	return result.regions[normaliseLocation(location)], nil
+}
+
+func (dp *AzureDataProcessor) listRegions(subscriptionID string) (map[string]*Region, error) {
+	client, err := dp.getARMClient()
+	if err != nil {
+		return nil, err
+	}
+
+	regions := map[string]*Region{}
+	for location, err := range ListARMResources[armLocation](dp.ctx, client, "/subscriptions/"+subscriptionID+"/locations", subscriptionsAPIVersion) {
+		if err != nil {
+			return nil, err
+		}
+		if location.Name == nil {
+			continue
+		}
+
+		region := &Region{
+			Name:          normaliseLocation(*location.Name),
+			PairedRegions: make([]string, 0),
+		}
+		if metadata := location.Metadata; metadata != nil {
+			if metadata.Geography != nil {
+				region.Geography = *metadata.Geography
+			}
+			if metadata.GeographyGroup != nil {
+				region.GeographyGroup = *metadata.GeographyGroup
+			}
+			for _, paired := range metadata.PairedRegion {
+				if paired.Name != nil {
+					region.PairedRegions = append(region.PairedRegions, normaliseLocation(*paired.Name))
+				}
+			}
+		}
+		regions[region.Name] = region
+	}
+	return regions, nil
+}
+
+// IsPairedWith reports whether Azure pairs the region with the other.
+func (r *Region) IsPairedWith(location string) bool {
+	return containsString(r.PairedRegions, normaliseLocation(location))
+}
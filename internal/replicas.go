@@ -0,0 +1,130 @@
+package internal
+
+import (
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/postgresql/armpostgresqlflexibleservers"
+)
+
+// Replica is a read replica of a server.
+type Replica struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Location string `json:"location"`
+	// Geography and PairedRegion are set when the regions' metadata could be read. PairedRegion is true when the
+	// replica is in the region Azure pairs with the primary's.
+	Geography    string `json:"geography,omitempty"`
+	PairedRegion bool   `json:"paired_region"`
+}
+
+// GetReplicas lists the read replicas of a server. A server without replicas returns an empty list.
+func (dp *AzureDataProcessor) GetReplicas(serverID string) ([]Replica, error) {
+	client, err := dp.getARMClient()
+	if err != nil {
+		return nil, err
+	}
+
+	replicas := make([]Replica, 0)
+	for replica, err := range ListARMResources[armpostgresqlflexibleservers.Server](dp.ctx, client, serverID+"/replicas", flexibleServersAPIVersion) {
+		if err != nil {
+			return nil, err
+		}
+
+		r := Replica{}
+		if replica.ID != nil {
+			r.ID = *replica.ID
+		}
+		if replica.Name != nil {
+			r.Name = *replica.Name
+		}
+		if replica.Location != nil {
+			r.Location = normaliseLocation(*replica.Location)
+		}
+		replicas = append(replicas, r)
+	}
+	return replicas, nil
+}
+
+// HasCrossRegionReplica reports whether any replica is in a different region to the primary.
+func HasCrossRegionReplica(primaryLocation string, replicas []Replica) bool {
+	primaryLocation = normaliseLocation(primaryLocation)
+	for _, replica := range replicas {
+		if replica.Location != "" && replica.Location != primaryLocation {
+			return true
+		}
+	}
+	return false
+}
+
+// Replication describes a server's place in a replication topology.
+type Replication struct {
+	// Role is the replication role reported by Azure, e.g. Primary, AsyncReplica or GeoAsyncReplica.
+	// It is empty when the role could not be determined.
+	Role      string `json:"role"`
+	IsReplica bool   `json:"is_replica"`
+	// SourceServerID is the primary a replica replicates from.
+	SourceServerID string `json:"source_server_id,omitempty"`
+	// ReplicaCount and ReplicaRegions summarise the replicas of a primary. They are omitted for replicas, and when
+	// the replicas could not be listed.
+	ReplicaCount   *int     `json:"replica_count,omitempty"`
+	ReplicaRegions []string `json:"replica_regions,omitempty"`
+	// Region is the metadata of the server's own region, set when it could be read.
+	Region *Region `json:"region,omitempty"`
+	// SourceLocation, SourceGeography and SourcePairedRegion describe a replica's primary, and are set when the
+	// primary could be read. SourcePairedRegion is true when the primary is in the region Azure pairs with the
+	// replica's.
+	SourceLocation     string `json:"source_location,omitempty"`
+	SourceGeography    string `json:"source_geography,omitempty"`
+	SourcePairedRegion *bool  `json:"source_paired_region,omitempty"`
+}
+
+type armLocatedResource struct {
+	Location *string `json:"location"`
+}
+
+// GetServerLocation reads the region of a server, e.g. a replica's primary, by its resource ID.
+func (dp *AzureDataProcessor) GetServerLocation(serverID string) (string, error) {
+	client, err := dp.getARMClient()
+	if err != nil {
+		return "", err
+	}
+
+	resource := &armLocatedResource{}
+	if err := client.Get(dp.ctx, serverID, flexibleServersAPIVersion, resource); err != nil {
+		return "", err
+	}
+	if resource.Location == nil {
+		return "", nil
+	}
+	return normaliseLocation(*resource.Location), nil
+}
+
+// NewReplication reads the replication role from the extended server, which may be nil when it could not be fetched.
+func NewReplication(extended *ExtendedServer) *Replication {
+	replication := &Replication{}
+	if extended == nil || extended.Properties == nil {
+		return replication
+	}
+
+	if extended.Properties.ReplicationRole != nil {
+		replication.Role = *extended.Properties.ReplicationRole
+	}
+	if extended.Properties.SourceServerResourceID != nil && *extended.Properties.SourceServerResourceID != "" {
+		replication.SourceServerID = *extended.Properties.SourceServerResourceID
+	}
+	replication.IsReplica = strings.Contains(strings.ToLower(replication.Role), "replica") || replication.SourceServerID != ""
+	return replication
+}
+
+// SetReplicas records the count and distinct regions of a primary's replicas.
+func (r *Replication) SetReplicas(replicas []Replica) {
+	count := len(replicas)
+	r.ReplicaCount = &count
+
+	r.ReplicaRegions = make([]string, 0)
+	for _, replica := range replicas {
+		if replica.Location != "" && !containsString(r.ReplicaRegions, replica.Location) {
+			r.ReplicaRegions = append(r.ReplicaRegions, replica.Location)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ResourceID is a parsed Azure resource ID. Child resources, such as a server's configurations or firewall rules,
+// are identified by their leaf resource type and name.
+type ResourceID struct {
+	ID             string
+	subscriptionID string
+	resourceGroup  string
+	// Provider is the resource provider namespace of the leaf resource, e.g. Microsoft.DBforPostgreSQL.
+	Provider string
+	// ResourceType is the full type of the leaf resource, e.g. Microsoft.DBforPostgreSQL/flexibleServers/configurations.
+	ResourceType string
+	// Name is the name of the leaf resource. It is empty for IDs that end in a resource type or provider namespace.
+	Name string
+	// Segments holds every key/value pair of the ID, keyed by the lower cased segment name, as Azure is inconsistent
+	// about casing, e.g. resourceGroups and resourcegroups.
+	Segments map[string]string
+}
+
+// SubscriptionID returns the subscription the resource belongs to, or an empty string for tenant level resources.
+func (r *ResourceID) SubscriptionID() string {
+	return r.subscriptionID
+}
+
+// ResourceGroup returns the resource group the resource belongs to, or an empty string for resources outside one.
+func (r *ResourceID) ResourceGroup() string {
+	return r.resourceGroup
+}
+
+// Segment returns the value following the named segment, matching the name case-insensitively.
+func (r *ResourceID) Segment(name string) string {
+	return r.Segments[strings.ToLower(name)]
+}
+
+// ParseResourceID parses an Azure resource ID, including child and extension resource IDs such as
+// .../flexibleServers/<name>/configurations/<param> or .../flexibleServers/<name>/providers/Microsoft.Insights/diagnosticSettings/<name>.
+// IDs ending in a provider namespace or resource type without a name are accepted, leaving Name empty.
+func ParseResourceID(resourceID string) (*ResourceID, error) {
+	if resourceID == "" {
+		return nil, errors.New("resourceID cannot be empty")
+	}
+
+	parts := strings.Split(strings.Trim(resourceID, "/"), "/")
+	result := &ResourceID{
+		ID:       resourceID,
+		Segments: map[string]string{},
+	}
+
+	types := make([]string, 0)
+	for i := 0; i < len(parts); i += 2 {
+		key := parts[i]
+		if key == "" {
+			return nil, fmt.Errorf("invalid Azure resource ID format: empty segment in %q", resourceID)
+		}
+
+		if strings.EqualFold(key, "providers") {
+			if i+1 >= len(parts) {
+				return nil, fmt.Errorf("invalid Azure resource ID format: missing provider namespace in %q", resourceID)
+			}
+			result.Provider = parts[i+1]
+			result.Name = ""
+			types = types[:0]
+			continue
+		}
+
+		if i+1 >= len(parts) {
+			// A trailing resource type without a name, e.g. a collection ID.
+			types = append(types, key)
+			result.Name = ""
+			break
+		}
+
+		value := parts[i+1]
+		result.Segments[strings.ToLower(key)] = value
+
+		switch {
+		case result.Provider == "" && strings.EqualFold(key, "subscriptions"):
+			result.subscriptionID = value
+		case result.Provider == "" && strings.EqualFold(key, "resourceGroups"):
+			result.resourceGroup = value
+		default:
+			types = append(types, key)
+		}
+		result.Name = value
+	}
+
+	if result.Provider != "" {
+		result.ResourceType = strings.Join(append([]string{result.Provider}, types...), "/")
+	}
+
+	return result, nil
+}
@@ -0,0 +1,118 @@
+package internal
+
+import "testing"
+
+func TestParseResourceID(t *testing.T) {
+	const server = "/subscriptions/sub-a/resourceGroups/rg-a/providers/Microsoft.DBforPostgreSQL/flexibleServers/server-a"
+
+	tests := []struct {
+		name             string
+		id               string
+		wantSubscription string
+		wantGroup        string
+		wantProvider     string
+		wantType         string
+		wantName         string
+		wantServer       string
+	}{
+		{
+			name:             "server",
+			id:               server,
+			wantSubscription: "sub-a",
+			wantGroup:        "rg-a",
+			wantProvider:     "Microsoft.DBforPostgreSQL",
+			wantType:         "Microsoft.DBforPostgreSQL/flexibleServers",
+			wantName:         "server-a",
+			wantServer:       "server-a",
+		},
+		{
+			name:             "firewall rule",
+			id:               server + "/firewallRules/allow-office",
+			wantSubscription: "sub-a",
+			wantGroup:        "rg-a",
+			wantProvider:     "Microsoft.DBforPostgreSQL",
+			wantType:         "Microsoft.DBforPostgreSQL/flexibleServers/firewallRules",
+			wantName:         "allow-office",
+			wantServer:       "server-a",
+		},
+		{
+			name:             "configuration",
+			id:               server + "/configurations/require_secure_transport",
+			wantSubscription: "sub-a",
+			wantGroup:        "rg-a",
+			wantProvider:     "Microsoft.DBforPostgreSQL",
+			wantType:         "Microsoft.DBforPostgreSQL/flexibleServers/configurations",
+			wantName:         "require_secure_transport",
+			wantServer:       "server-a",
+		},
+		{
+			name:             "extension resource",
+			id:               server + "/providers/Microsoft.Insights/diagnosticSettings/to-workspace",
+			wantSubscription: "sub-a",
+			wantGroup:        "rg-a",
+			wantProvider:     "Microsoft.Insights",
+			wantType:         "Microsoft.Insights/diagnosticSettings",
+			wantName:         "to-workspace",
+			wantServer:       "server-a",
+		},
+		{
+			name:             "collection",
+			id:               server + "/configurations",
+			wantSubscription: "sub-a",
+			wantGroup:        "rg-a",
+			wantProvider:     "Microsoft.DBforPostgreSQL",
+			wantType:         "Microsoft.DBforPostgreSQL/flexibleServers/configurations",
+			wantServer:       "server-a",
+		},
+		{
+			name:             "subscription",
+			id:               "/subscriptions/sub-a",
+			wantSubscription: "sub-a",
+			wantName:         "sub-a",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := ParseResourceID(tt.id)
+			if err != nil {
+				t.Fatalf("ParseResourceID(%q): %v", tt.id, err)
+			}
+
+			if parsed.ID != tt.id {
+				t.Errorf("ID = %q, want %q", parsed.ID, tt.id)
+			}
+			if got := parsed.SubscriptionID(); got != tt.wantSubscription {
+				t.Errorf("SubscriptionID() = %q, want %q", got, tt.wantSubscription)
+			}
+			if got := parsed.ResourceGroup(); got != tt.wantGroup {
+				t.Errorf("ResourceGroup() = %q, want %q", got, tt.wantGroup)
+			}
+			if parsed.Provider != tt.wantProvider {
+				t.Errorf("Provider = %q, want %q", parsed.Provider, tt.wantProvider)
+			}
+			if parsed.ResourceType != tt.wantType {
+				t.Errorf("ResourceType = %q, want %q", parsed.ResourceType, tt.wantType)
+			}
+			if parsed.Name != tt.wantName {
+				t.Errorf("Name = %q, want %q", parsed.Name, tt.wantName)
+			}
+			if got := parsed.Segment("FlexibleServers"); got != tt.wantServer {
+				t.Errorf("Segment(FlexibleServers) = %q, want %q", got, tt.wantServer)
+			}
+		})
+	}
+}
+
+func TestParseResourceIDMalformed(t *testing.T) {
+	for _, id := range []string{
+		"",
+		"/subscriptions/sub-a//rg-a",
+		"/subscriptions/sub-a/resourceGroups/rg-a/providers",
+		"/subscriptions/sub-a/resourceGroups/rg-a/providers/Microsoft.DBforPostgreSQL/flexibleServers/server-a/providers",
+	} {
+		if parsed, err := ParseResourceID(id); err == nil {
+			t.Errorf("ParseResourceID(%q) = %+v, want an error", id, parsed)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+)
+
+const roleAssignmentsAPIVersion = "2022-04-01"
+
+// privilegedRoles are the built-in roles that can manage the server or grant access to it.
+var privilegedRoles = []string{"Owner", "Contributor", "User Access Administrator", "Role Based Access Control Administrator"}
+
+// RoleAssignment is an Azure RBAC role assignment that applies to a server.
+type RoleAssignment struct {
+	ID          string `json:"id"`
+	PrincipalID string `json:"principal_id"`
+	// PrincipalType is User, Group, ServicePrincipal or ForeignGroup.
+	PrincipalType    string `json:"principal_type,omitempty"`
+	RoleDefinitionID string `json:"role_definition_id"`
+	// RoleName is the role definition's name, e.g. Owner, when the definition could be read.
+	RoleName string `json:"role_name,omitempty"`
+	// Scope is the server, resource group, subscription or management group ID the role is assigned at.
+	Scope     string `json:"scope"`
+	Inherited bool   `json:"inherited"`
+	Condition string `json:"condition,omitempty"`
+}
+
+type armRoleAssignment struct {
+	ID         *string `json:"id"`
+	Properties *struct {
+		PrincipalID      *string `json:"principalId"`
+		PrincipalType    *string `json:"principalType"`
+		RoleDefinitionID *string `json:"roleDefinitionId"`
+		Scope            *string `json:"scope"`
+		Condition        *string `json:"condition"`
+	} `json:"properties"`
+}
+
+type armRoleDefinition struct {
+	Properties *struct {
+		RoleName *string `json:"roleName"`
+	} `json:"properties"`
+}
+
+// GetRoleAssignments lists the role assignments applying to a server. Assignments inherited from its resource group,
+// subscription and management groups are included when includeInherited is set.
+func (dp *AzureDataProcessor) GetRoleAssignments(serverID string, includeInherited bool) ([]RoleAssignment, error) {
+	client, err := dp.getARMClient()
+	if err != nil {
+		return nil, err
+	}
+
+	assignments := make([]RoleAssignment, 0)
+	path := fmt.Sprintf("%s/providers/Microsoft.Authorization/roleAssignments", serverID)
+	for assignment, err := range ListARMResources[armRoleAssignment](dp.ctx, client, path, roleAssignmentsAPIVersion) {
+		if err != nil {
+			return nil, err
+		}
+
+		a := RoleAssignment{}
+		if assignment.ID != nil {
+			a.ID = *assignment.ID
+		}
+		if properties := assignment.Properties; properties != nil {
+			if properties.PrincipalID != nil {
+				a.PrincipalID = *properties.PrincipalID
+			}
+			if properties.PrincipalType != nil {
+				a.PrincipalType = *properties.PrincipalType
+			}
+			if properties.RoleDefinitionID != nil {
+				a.RoleDefinitionID = *properties.RoleDefinitionID
+			}
+			if properties.Scope != nil {
+				a.Scope = *properties.Scope
+			}
+			if properties.Condition != nil {
+				a.Condition = *properties.Condition
+			}
+		}
+		a.Inherited = !strings.EqualFold(strings.TrimSuffix(a.Scope, "/"), strings.TrimSuffix(serverID, "/"))
+		if a.Inherited && !includeInherited {
+			continue
+		}
+		if a.RoleDefinitionID != "" {
+			a.RoleName = dp.getRoleName(a.RoleDefinitionID)
+		}
+		assignments = append(assignments, a)
+	}
+	return assignments, nil
+}
+
+// getRoleName resolves a role definition's name. Servers share a handful of role definitions, so names, including
+// failures, are cached for the rest of the run, and an empty string is returned when the name can't be read.
+func (dp *AzureDataProcessor) getRoleName(roleDefinitionID string) string {
+	dp.roleNamesMu.Lock()
+	defer dp.roleNamesMu.Unlock()
+
+	key := strings.ToLower(roleDefinitionID)
+	if name, ok := dp.roleNames[key]; ok {
+		return name
+	}
+
+	name := ""
+	client, err := dp.getARMClient()
+	if err == nil {
+		definition := &armRoleDefinition{}
+		err = client.Get(dp.ctx, roleDefinitionID, roleAssignmentsAPIVersion, definition)
+		if err == nil && definition.Properties != nil && definition.Properties.RoleName != nil {
+			name = *definition.Properties.RoleName
+		}
+	}
+	if name == "" {
+		dp.logger.Warn("unable to resolve the role definition name", "role_definition_id", roleDefinitionID, "error", err)
+	}
+
+	dp.roleNames[key] = name
+	return name
+}
+
+// Privileged reports whether the assignment grants one of the built-in roles that can manage the server or grant
+// access to it.
+func (a RoleAssignment) Privileged() bool {
+	for _, role := range privilegedRoles {
+		if strings.EqualFold(a.RoleName, role) {
+			return true
+		}
+	}
+	return false
+}
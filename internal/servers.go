@@ -0,0 +1,234 @@
+package internal
+
+import (
+	"context"
+	"iter"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/postgresql/armpostgresqlflexibleservers"
+	"github.com/hashicorp/go-hclog"
+)
+
+// PostgresServerLister is the source of the servers a run assesses.
+type PostgresServerLister interface {
+	ListServers(ctx context.Context) iter.Seq2[*armpostgresqlflexibleservers.Server, error]
+}
+
+// subscriptionIDs returns the subscriptions to scan in this run, from subscription_ids or otherwise subscription_id.
+// Both accept a comma separated list.
+func (dp *AzureDataProcessor) subscriptionIDs() []string {
+	if ids := ConfigList(dp.config, "subscription_ids", nil); len(ids) > 0 {
+		return ids
+	}
+	return ConfigList(dp.config, "subscription_id", nil)
+}
+
+// resourceGroups returns the resource groups allowlisted by resource_groups, de-duplicated ignoring case as Azure
+// is inconsistent about resource group casing. An empty list means every resource group.
+func (dp *AzureDataProcessor) resourceGroups() []string {
+	groups := make([]string, 0)
+	seen := map[string]bool{}
+	for _, group := range ConfigList(dp.config, "resource_groups", nil) {
+		if seen[strings.ToLower(group)] {
+			continue
+		}
+		seen[strings.ToLower(group)] = true
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+// maxPageFailures is the number of consecutive times a page of servers may fail before the subscription is abandoned.
+const maxPageFailures = 3
+
+// pageRetryDelay is the wait before a failed page of servers is first retried, doubling with each further failure.
+var pageRetryDelay = time.Second
+
+// ServerKinds selects the kinds of server listed alongside flexible servers, which are always listed.
+type ServerKinds struct {
+	// SingleServers also lists the legacy single servers of each subscription or resource group.
+	SingleServers bool
+	// Clusters also lists the Azure Cosmos DB for PostgreSQL clusters of each subscription or resource group.
+	Clusters bool
+	// ArcServers also lists the Azure Arc-enabled PostgreSQL instances of each subscription or resource group.
+	ArcServers bool
+}
+
+// ServerKindsFromConfig reads the include_* options selecting the kinds of server to list.
+func ServerKindsFromConfig(config map[string]string) ServerKinds {
+	return ServerKinds{
+		SingleServers: ConfigBool(config, "include_single_server"),
+		Clusters:      ConfigBool(config, "include_clusters"),
+		ArcServers:    ConfigBool(config, "include_arc_servers"),
+	}
+}
+
+// Describe names the kinds of server listed, e.g. "Azure PostgreSQL Flexible Servers or single servers".
+func (k ServerKinds) Describe() string {
+	kinds := []string{"Azure PostgreSQL Flexible Servers"}
+	if k.SingleServers {
+		kinds = append(kinds, "single servers")
+	}
+	if k.Clusters {
+		kinds = append(kinds, "Azure Cosmos DB for PostgreSQL clusters")
+	}
+	if k.ArcServers {
+		kinds = append(kinds, "Azure Arc-enabled PostgreSQL instances")
+	}
+	return joinWords(kinds, "or")
+}
+
+// joinWords joins the words into a list for a sentence, e.g. "a, b or c" with the conjunction "or".
+func joinWords(words []string, conjunction string) string {
+	if len(words) <= 1 {
+		return strings.Join(words, "")
+	}
+	return strings.Join(words[:len(words)-1], ", ") + " " + conjunction + " " + words[len(words)-1]
+}
+
+// ARMListed reports whether any of the kinds is listed through ARM rather than the Azure SDK.
+func (k ServerKinds) ARMListed() bool {
+	return k.SingleServers || k.Clusters || k.ArcServers
+}
+
+// AzureServerLister lists the flexible servers of a set of subscriptions through the Azure SDK, and optionally
+// their single servers, clusters and Arc-enabled instances through ARM.
+type AzureServerLister struct {
+	logger          hclog.Logger
+	credential      azcore.TokenCredential
+	options         *arm.ClientOptions
+	subscriptionIDs []string
+	// resourceGroups limits listing to these resource groups when set.
+	resourceGroups []string
+	kinds          ServerKinds
+}
+
+func NewAzureServerLister(logger hclog.Logger, credential azcore.TokenCredential, options *arm.ClientOptions, subscriptionIDs []string, resourceGroups []string, kinds ServerKinds) *AzureServerLister {
+	return &AzureServerLister{
+		logger:          logger,
+		credential:      credential,
+		options:         options,
+		subscriptionIDs: subscriptionIDs,
+		resourceGroups:  resourceGroups,
+		kinds:           kinds,
+	}
+}
+
+// GetPostgresFlexibleServers lists the servers to assess in this run.
+func (dp *AzureDataProcessor) GetPostgresFlexibleServers() iter.Seq2[*armpostgresqlflexibleservers.Server, error] {
+	return dp.serverLister.ListServers(dp.ctx)
+}
+
+// ListServers lists the servers of every subscription, or only those in the allowlisted resource groups when any are
+// set, followed by their single servers, clusters and Arc-enabled instances when enabled. A page that fails is
+// retried, backing off between attempts, until it has failed maxPageFailures times. A subscription or resource group
+// that still fails yields a *CollectionError and is skipped, so the remaining ones are still listed.
+func (l *AzureServerLister) ListServers(ctx context.Context) iter.Seq2[*armpostgresqlflexibleservers.Server, error] {
+	return func(yield func(*armpostgresqlflexibleservers.Server, error) bool) {
+		for _, subscriptionID := range l.subscriptionIDs {
+			client, err := armpostgresqlflexibleservers.NewServersClient(subscriptionID, l.credential, l.options)
+			if err != nil {
+				l.logger.Error("unable to create Azure PostgreSQL client", "subscription", subscriptionID, "error", err)
+				if !yield(nil, &CollectionError{Operation: "create servers client", SubscriptionID: subscriptionID, Err: err}) {
+					return
+				}
+				continue
+			}
+
+			l.logger.Debug("Azure PostgreSQL client created successfully", "client", client)
+
+			var armClient *ARMClient
+			if l.kinds.ARMListed() {
+				armClient, err = NewARMClient(l.credential, l.options)
+				if err != nil {
+					l.logger.Error("unable to create Azure Resource Manager client", "subscription", subscriptionID, "error", err)
+					if !yield(nil, &CollectionError{Operation: "create resource manager client", SubscriptionID: subscriptionID, Err: err}) {
+						return
+					}
+				}
+			}
+
+			if len(l.resourceGroups) == 0 {
+				pager := client.NewListPager(nil)
+				if !listServerPages(ctx, l.logger, pager, func(page armpostgresqlflexibleservers.ServersClientListResponse) []*armpostgresqlflexibleservers.Server {
+					return page.Value
+				}, &CollectionError{Operation: "list servers", SubscriptionID: subscriptionID}, yield) {
+					return
+				}
+				if armClient != nil && !l.listARMServers(ctx, armClient, subscriptionID, "", yield) {
+					return
+				}
+				continue
+			}
+
+			for _, resourceGroup := range l.resourceGroups {
+				pager := client.NewListByResourceGroupPager(resourceGroup, nil)
+				if !listServerPages(ctx, l.logger, pager, func(page armpostgresqlflexibleservers.ServersClientListByResourceGroupResponse) []*armpostgresqlflexibleservers.Server {
+					return page.Value
+				}, &CollectionError{Operation: "list servers", SubscriptionID: subscriptionID, ResourceGroup: resourceGroup}, yield) {
+					return
+				}
+				if armClient != nil && !l.listARMServers(ctx, armClient, subscriptionID, resourceGroup, yield) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// listARMServers yields the servers of the kinds listed through ARM. It returns false once the consumer stops iterating.
+func (l *AzureServerLister) listARMServers(ctx context.Context, client *ARMClient, subscriptionID string, resourceGroup string, yield func(*armpostgresqlflexibleservers.Server, error) bool) bool {
+	if l.kinds.SingleServers && !l.listSingleServers(ctx, client, subscriptionID, resourceGroup, yield) {
+		return false
+	}
+	if l.kinds.Clusters && !l.listClusters(ctx, client, subscriptionID, resourceGroup, yield) {
+		return false
+	}
+	if l.kinds.ArcServers && !l.listArcServers(ctx, client, subscriptionID, resourceGroup, yield) {
+		return false
+	}
+	return true
+}
+
+// listServerPages yields the servers of every page, retrying failed pages with an exponential backoff that stops
+// when ctx is done. The scope identifies the listing in the error yielded when it is abandoned. It returns false
+// once the consumer stops iterating.
+func listServerPages[T any](ctx context.Context, logger hclog.Logger, pager *runtime.Pager[T], servers func(T) []*armpostgresqlflexibleservers.Server, scope *CollectionError, yield func(*armpostgresqlflexibleservers.Server, error) bool) bool {
+	failures := 0
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			// The pager only advances on success, so the failed page is fetched again on the next iteration.
+			failures++
+			fatal := IsFatalError(err)
+			if !fatal && failures < maxPageFailures {
+				delay := pageRetryDelay << (failures - 1)
+				logger.Warn("unable to list a page of Azure PostgreSQL servers, retrying", "scope", scope.Scope(), "attempt", failures, "delay", delay, "error", err)
+				select {
+				case <-time.After(delay):
+					continue
+				case <-ctx.Done():
+					err = ctx.Err()
+					fatal = true
+				}
+			}
+
+			logger.Error("unable to list Azure PostgreSQL servers", "scope", scope.Scope(), "error", err)
+			scope.Err = err
+			scope.Fatal = fatal
+			return yield(nil, scope)
+		}
+		failures = 0
+
+		for _, server := range servers(page) {
+			if !yield(server, nil) {
+				return false
+			}
+		}
+	}
+	return true
+}
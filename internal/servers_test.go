@@ -0,0 +1,106 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/postgresql/armpostgresqlflexibleservers"
+	"github.com/hashicorp/go-hclog"
+)
+
+// failingPager returns a single page pager whose page fails the given number of times before it is returned.
+func failingPager(failures int, calls *int) *runtime.Pager[[]*armpostgresqlflexibleservers.Server] {
+	return runtime.NewPager(runtime.PagingHandler[[]*armpostgresqlflexibleservers.Server]{
+		More: func([]*armpostgresqlflexibleservers.Server) bool {
+			return false
+		},
+		Fetcher: func(context.Context, *[]*armpostgresqlflexibleservers.Server) ([]*armpostgresqlflexibleservers.Server, error) {
+			*calls++
+			if *calls <= failures {
+				return nil, errors.New("service unavailable")
+			}
+			return []*armpostgresqlflexibleservers.Server{{Name: to.Ptr("a")}}, nil
+		},
+	})
+}
+
+func TestListServerPagesRetriesWithBackoff(t *testing.T) {
+	defer func(delay time.Duration) { pageRetryDelay = delay }(pageRetryDelay)
+	pageRetryDelay = 10 * time.Millisecond
+
+	calls := 0
+	names := make([]string, 0)
+	start := time.Now()
+	listServerPages(context.Background(), hclog.NewNullLogger(), failingPager(maxPageFailures-1, &calls), func(page []*armpostgresqlflexibleservers.Server) []*armpostgresqlflexibleservers.Server {
+		return page
+	}, &CollectionError{Operation: "list servers", SubscriptionID: "sub-a"}, func(server *armpostgresqlflexibleservers.Server, err error) bool {
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+			return true
+		}
+		names = append(names, *server.Name)
+		return true
+	})
+
+	if calls != maxPageFailures {
+		t.Errorf("page fetched %d times, want %d", calls, maxPageFailures)
+	}
+	if len(names) != 1 {
+		t.Errorf("servers = %v, want [a]", names)
+	}
+	// The retries wait 10ms and then 20ms.
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Errorf("retries took %v, want at least 30ms of backoff", elapsed)
+	}
+}
+
+func TestListServerPagesGivesUp(t *testing.T) {
+	defer func(delay time.Duration) { pageRetryDelay = delay }(pageRetryDelay)
+	pageRetryDelay = time.Millisecond
+
+	calls := 0
+	var listErr *CollectionError
+	listServerPages(context.Background(), hclog.NewNullLogger(), failingPager(maxPageFailures, &calls), func(page []*armpostgresqlflexibleservers.Server) []*armpostgresqlflexibleservers.Server {
+		return page
+	}, &CollectionError{Operation: "list servers", SubscriptionID: "sub-a"}, func(server *armpostgresqlflexibleservers.Server, err error) bool {
+		if !errors.As(err, &listErr) {
+			t.Errorf("yielded (%v, %v), want a *CollectionError", server, err)
+		}
+		return true
+	})
+
+	if calls != maxPageFailures {
+		t.Errorf("page fetched %d times, want %d", calls, maxPageFailures)
+	}
+	if listErr == nil || listErr.Fatal {
+		t.Errorf("error = %+v, want a non-fatal listing error", listErr)
+	}
+}
+
+func TestListServerPagesStopsBackoffWhenCancelled(t *testing.T) {
+	defer func(delay time.Duration) { pageRetryDelay = delay }(pageRetryDelay)
+	pageRetryDelay = time.Hour
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	calls := 0
+	var listErr *CollectionError
+	listServerPages(ctx, hclog.NewNullLogger(), failingPager(maxPageFailures, &calls), func(page []*armpostgresqlflexibleservers.Server) []*armpostgresqlflexibleservers.Server {
+		return page
+	}, &CollectionError{Operation: "list servers", SubscriptionID: "sub-a"}, func(server *armpostgresqlflexibleservers.Server, err error) bool {
+		errors.As(err, &listErr)
+		return true
+	})
+
+	if calls != 1 {
+		t.Errorf("page fetched %d times, want 1", calls)
+	}
+	if listErr == nil || !listErr.Fatal || !errors.Is(listErr, context.DeadlineExceeded) {
+		t.Errorf("error = %+v, want a fatal deadline exceeded error", listErr)
+	}
+}
@@ -0,0 +1,180 @@
+package internal
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/postgresql/armpostgresqlflexibleservers"
+)
+
+// singleServersAPIVersion is the ARM API version of the Microsoft.DBforPostgreSQL/servers resource. Single servers
+// are read through the generic ARM client, as the plugin does not depend on the armpostgresql SDK.
+const singleServersAPIVersion = "2017-12-01"
+
+const (
+	ServerTypeFlexible = "flexible"
+	ServerTypeSingle   = "single"
+	// ServerTypeCluster is an Azure Cosmos DB for PostgreSQL cluster.
+	ServerTypeCluster = "cluster"
+	// ServerTypeArc is an Azure Arc-enabled PostgreSQL instance, run outside Azure by Arc data services.
+	ServerTypeArc = "arc"
+)
+
+// ServerTypeOf returns whether a server ID refers to a single server, a cluster, an Arc-enabled instance or a
+// flexible server.
+func ServerTypeOf(serverID string) string {
+	idparts, err := ParseAzureResourceID(serverID)
+	if err != nil || idparts.Segment("flexibleServers") != "" {
+		return ServerTypeFlexible
+	}
+	if idparts.Segment("serverGroupsv2") != "" {
+		return ServerTypeCluster
+	}
+	if idparts.Segment("postgresInstances") != "" {
+		return ServerTypeArc
+	}
+	if idparts.Segment("servers") != "" {
+		return ServerTypeSingle
+	}
+	return ServerTypeFlexible
+}
+
+// SingleServerProperties are the single server settings that have no flexible server equivalent in the SDK model.
+type SingleServerProperties struct {
+	SSLEnforcement      *string `json:"ssl_enforcement,omitempty"`
+	MinimalTLSVersion   *string `json:"minimal_tls_version,omitempty"`
+	PublicNetworkAccess *string `json:"public_network_access,omitempty"`
+	UserVisibleState    *string `json:"user_visible_state,omitempty"`
+}
+
+// SingleServer is the subset of the single server resource that the flexible server SDK model doesn't carry.
+type SingleServer struct {
+	Properties *SingleServerResourceProperties `json:"properties,omitempty"`
+}
+
+type SingleServerResourceProperties struct {
+	SSLEnforcement      *string              `json:"sslEnforcement,omitempty"`
+	MinimalTLSVersion   *string              `json:"minimalTlsVersion,omitempty"`
+	PublicNetworkAccess *string              `json:"publicNetworkAccess,omitempty"`
+	UserVisibleState    *string              `json:"userVisibleState,omitempty"`
+	StorageProfile      *SingleServerStorage `json:"storageProfile,omitempty"`
+}
+
+type SingleServerStorage struct {
+	BackupRetentionDays *int32  `json:"backupRetentionDays,omitempty"`
+	GeoRedundantBackup  *string `json:"geoRedundantBackup,omitempty"`
+}
+
+// GetSingleServer fetches a single server through ARM.
+func (dp *AzureDataProcessor) GetSingleServer(serverID string) (*SingleServer, error) {
+	client, err := dp.getARMClient()
+	if err != nil {
+		return nil, err
+	}
+
+	server := &SingleServer{}
+	if err := client.Get(dp.ctx, serverID, singleServersAPIVersion, server); err != nil {
+		return nil, err
+	}
+	return server, nil
+}
+
+// NewSingleServerProperties extracts the single server specific settings for the policy input.
+func NewSingleServerProperties(server *SingleServer) *SingleServerProperties {
+	properties := &SingleServerProperties{}
+	if server.Properties != nil {
+		properties.SSLEnforcement = server.Properties.SSLEnforcement
+		properties.MinimalTLSVersion = server.Properties.MinimalTLSVersion
+		properties.PublicNetworkAccess = server.Properties.PublicNetworkAccess
+		properties.UserVisibleState = server.Properties.UserVisibleState
+	}
+	return properties
+}
+
+// SecureTransportRequired reports whether SSL enforcement is known, and if so whether it is enabled.
+func (p *SingleServerProperties) SecureTransportRequired() (bool, bool) {
+	if p.SSLEnforcement == nil {
+		return false, false
+	}
+	return strings.EqualFold(*p.SSLEnforcement, "Enabled"), true
+}
+
+// MinProtocolVersion returns the minimal TLS version in the TLSv1.2 form flexible servers use for
+// ssl_min_protocol_version. It is empty when no minimum is enforced.
+func (p *SingleServerProperties) MinProtocolVersion() string {
+	if p.MinimalTLSVersion == nil || !strings.HasPrefix(*p.MinimalTLSVersion, "TLS1_") {
+		return ""
+	}
+	return "TLSv1." + strings.TrimPrefix(*p.MinimalTLSVersion, "TLS1_")
+}
+
+// NewSingleServerBackupConfig summarises a single server's backup configuration, which it keeps in its storage
+// profile rather than in a backup block.
+func NewSingleServerBackupConfig(server *SingleServer) *BackupConfig {
+	backup := &BackupConfig{
+		GeoRedundantBackup: backupUnknown,
+	}
+	if server.Properties == nil || server.Properties.StorageProfile == nil {
+		return backup
+	}
+
+	backup.RetentionDays = server.Properties.StorageProfile.BackupRetentionDays
+	if server.Properties.StorageProfile.GeoRedundantBackup != nil {
+		backup.GeoRedundantBackup = *server.Properties.StorageProfile.GeoRedundantBackup
+	}
+	return backup
+}
+
+// GetSingleServerFirewallRules lists the firewall rules of a single server.
+func (dp *AzureDataProcessor) GetSingleServerFirewallRules(serverID string) ([]FirewallRule, error) {
+	return dp.getARMFirewallRules(serverID, singleServersAPIVersion)
+}
+
+// getARMFirewallRules lists firewall rules through ARM, for server kinds whose firewall rules share the flexible
+// server rule's shape.
+func (dp *AzureDataProcessor) getARMFirewallRules(serverID string, apiVersion string) ([]FirewallRule, error) {
+	client, err := dp.getARMClient()
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make([]FirewallRule, 0)
+	for rule, err := range ListARMResources[armpostgresqlflexibleservers.FirewallRule](dp.ctx, client, serverID+"/firewallRules", apiVersion) {
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, newFirewallRule(&rule))
+	}
+	return rules, nil
+}
+
+// listSingleServers yields the single servers of a subscription, or of one of its resource groups when set.
+// The single server resource shares enough of its shape with a flexible server to be decoded into the SDK model.
+// It returns false once the consumer stops iterating.
+func (l *AzureServerLister) listSingleServers(ctx context.Context, client *ARMClient, subscriptionID string, resourceGroup string, yield func(*armpostgresqlflexibleservers.Server, error) bool) bool {
+	path := "/subscriptions/" + subscriptionID
+	if resourceGroup != "" {
+		path += "/resourceGroups/" + resourceGroup
+	}
+	path += "/providers/Microsoft.DBforPostgreSQL/servers"
+
+	for server, err := range ListARMResources[armpostgresqlflexibleservers.Server](ctx, client, path, singleServersAPIVersion) {
+		if err != nil {
+			l.logger.Error("unable to list Azure PostgreSQL single servers", "subscription", subscriptionID, "resource_group", resourceGroup, "error", err)
+			return yield(nil, &CollectionError{
+				Operation:      "list single servers",
+				SubscriptionID: subscriptionID,
+				ResourceGroup:  resourceGroup,
+				Err:            err,
+				Fatal:          IsFatalError(err),
+			})
+		}
+		if server.ID == nil || ServerTypeOf(*server.ID) != ServerTypeSingle {
+			continue
+		}
+		if !yield(&server, nil) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,344 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/streaming"
+	"github.com/compliance-framework/agent/runner"
+	"github.com/compliance-framework/agent/runner/proto"
+	"github.com/hashicorp/go-hclog"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const (
+	SinkAPI  = "api"
+	SinkBlob = "blob"
+	SinkFile = "file"
+)
+
+// EvidenceSink is a destination for the evidence, and optionally the collected data, produced by a run.
+type EvidenceSink interface {
+	// Name identifies the sink in errors and the run summary.
+	Name() string
+	WriteEvidence(ctx context.Context, batch *EvidenceBatch) error
+	WriteServerData(ctx context.Context, data *ServerData) error
+}
+
+// EvidenceBatch is evidence written to the sinks together. Its sequence number and creation time are fixed when the
+// batch is made, so sinks that name what they write after them replace rather than duplicate a retried batch.
+type EvidenceBatch struct {
+	// Sequence numbers the run's batches from 1.
+	Sequence int64
+	Created  time.Time
+	Evidence []*proto.Evidence
+}
+
+// NewEvidenceSinks builds the sinks listed in the comma separated sink config. It defaults to the compliance API,
+// along with the file sink when output_dir is set.
+func NewEvidenceSinks(config map[string]string, apiHelper runner.ApiHelper, cred azcore.TokenCredential, options *arm.ClientOptions, runID string) ([]EvidenceSink, error) {
+	names, err := sinkNames(config)
+	if err != nil {
+		return nil, err
+	}
+
+	sinks := make([]EvidenceSink, 0)
+	for _, name := range names {
+		switch name {
+		case SinkAPI:
+			sinks = append(sinks, &APISink{apiHelper: apiHelper})
+		case SinkBlob:
+			sink, err := NewBlobSink(config["blob_container_url"], cred, options, runID)
+			if err != nil {
+				return nil, err
+			}
+			sinks = append(sinks, sink)
+		case SinkFile:
+			sink, err := NewFileSink(config["output_dir"], runID)
+			if err != nil {
+				return nil, err
+			}
+			sinks = append(sinks, sink)
+		}
+	}
+
+	return sinks, nil
+}
+
+// sinkNames returns the lower cased names of the sinks selected by the sink config, returning an error for an
+// unknown sink.
+func sinkNames(config map[string]string) ([]string, error) {
+	if strings.TrimSpace(config["sink"]) == "" {
+		if config["output_dir"] != "" {
+			return []string{SinkAPI, SinkFile}, nil
+		}
+		return []string{SinkAPI}, nil
+	}
+
+	names := make([]string, 0)
+	for _, name := range strings.Split(config["sink"], ",") {
+		switch normalised := strings.ToLower(strings.TrimSpace(name)); normalised {
+		case "":
+			continue
+		case SinkAPI, SinkBlob, SinkFile:
+			names = append(names, normalised)
+		default:
+			return nil, fmt.Errorf("unknown sink %q, expected one of %s, %s, %s", name, SinkAPI, SinkBlob, SinkFile)
+		}
+	}
+	return names, nil
+}
+
+// validateSinkConfig checks the sink config and the settings each selected sink needs, returning the errors
+// NewEvidenceSinks would.
+func validateSinkConfig(config map[string]string) []error {
+	names, err := sinkNames(config)
+	if err != nil {
+		return []error{err}
+	}
+
+	var errs []error
+	for _, name := range names {
+		switch name {
+		case SinkBlob:
+			if err := validateBlobContainerURL(config["blob_container_url"]); err != nil {
+				errs = append(errs, err)
+			}
+		case SinkFile:
+			if err := validateOutputDir(config["output_dir"]); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errs
+}
+
+// APISink sends evidence to the compliance API through the agent.
+type APISink struct {
+	apiHelper runner.ApiHelper
+}
+
+func (s *APISink) Name() string {
+	return SinkAPI
+}
+
+func (s *APISink) WriteEvidence(ctx context.Context, batch *EvidenceBatch) error {
+	return s.apiHelper.CreateEvidence(ctx, batch.Evidence)
+}
+
+// WriteServerData is a no-op, as the compliance API only accepts evidence.
+func (s *APISink) WriteServerData(ctx context.Context, data *ServerData) error {
+	return nil
+}
+
+// DryRunSink logs a summary of each evidence instead of writing it anywhere, so policies can be developed without
+// touching a real backend.
+type DryRunSink struct {
+	logger hclog.Logger
+}
+
+func NewDryRunSink(logger hclog.Logger) *DryRunSink {
+	return &DryRunSink{logger: logger}
+}
+
+func (s *DryRunSink) Name() string {
+	return "dry-run"
+}
+
+func (s *DryRunSink) WriteEvidence(ctx context.Context, batch *EvidenceBatch) error {
+	for _, evidence := range batch.Evidence {
+		subjects := make([]string, 0)
+		for _, subject := range evidence.GetSubjects() {
+			subjects = append(subjects, subject.GetIdentifier())
+		}
+
+		s.logger.Info("Dry run, skipping evidence",
+			"uuid", evidence.GetUUID(),
+			"title", evidence.GetTitle(),
+			"policy", evidence.GetLabels()["_policy"],
+			"state", evidence.GetStatus().GetState().String(),
+			"subjects", subjects,
+			"labels", evidence.GetLabels(),
+		)
+	}
+	return nil
+}
+
+// WriteServerData is a no-op, as a dry run only reports the evidence.
+func (s *DryRunSink) WriteServerData(ctx context.Context, data *ServerData) error {
+	return nil
+}
+
+// FileSink writes each batch of evidence to its own file in a local directory, as a JSON array that can be replayed
+// to the compliance API later. Files are named <timestamp>-<run-id>-evidence-<n>.json after the batch's creation time
+// and sequence number, so they sort by time.
+type FileSink struct {
+	dir   string
+	runID string
+}
+
+func validateOutputDir(dir string) error {
+	if dir == "" {
+		return fmt.Errorf("output_dir is required for the %s sink", SinkFile)
+	}
+	return nil
+}
+
+// NewFileSink creates the output directory if it doesn't exist yet.
+func NewFileSink(dir string, runID string) (*FileSink, error) {
+	if err := validateOutputDir(dir); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("unable to create output_dir %s: %w", dir, err)
+	}
+	return &FileSink{
+		dir:   dir,
+		runID: runID,
+	}, nil
+}
+
+func (s *FileSink) Name() string {
+	return SinkFile
+}
+
+func (s *FileSink) WriteEvidence(ctx context.Context, batch *EvidenceBatch) error {
+	if len(batch.Evidence) == 0 {
+		return nil
+	}
+
+	items := make([]json.RawMessage, 0, len(batch.Evidence))
+	for _, evidence := range batch.Evidence {
+		item, err := protojson.Marshal(evidence)
+		if err != nil {
+			return err
+		}
+		items = append(items, item)
+	}
+	body, err := json.MarshalIndent(items, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%s-%s-evidence-%d.json", batch.Created.UTC().Format("20060102T150405Z"), s.runID, batch.Sequence)
+	path := filepath.Join(s.dir, name)
+	if err := os.WriteFile(path, body, 0o640); err != nil {
+		return fmt.Errorf("unable to write evidence to %s: %w", path, err)
+	}
+	return nil
+}
+
+// WriteServerData is a no-op, as the file sink only keeps evidence for replay.
+func (s *FileSink) WriteServerData(ctx context.Context, data *ServerData) error {
+	return nil
+}
+
+// BlobSink archives evidence and collected data as newline delimited JSON blobs in an Azure Storage container,
+// authenticating with the plugin's Azure credential. Blobs are partitioned by run ID, as
+// <run-id>/evidence-<n>.ndjson, numbered by evidence batch, and <run-id>/data-<n>.ndjson.
+type BlobSink struct {
+	containerURL string
+	runID        string
+	pipeline     runtime.Pipeline
+	// dataSequence numbers the data blobs.
+	dataSequence atomic.Int64
+}
+
+func validateBlobContainerURL(containerURL string) error {
+	if containerURL == "" {
+		return fmt.Errorf("blob_container_url is required for the %s sink", SinkBlob)
+	}
+	if _, err := url.ParseRequestURI(containerURL); err != nil {
+		return fmt.Errorf("blob_container_url is not a valid URL: %w", err)
+	}
+	return nil
+}
+
+func NewBlobSink(containerURL string, cred azcore.TokenCredential, options *arm.ClientOptions, runID string) (*BlobSink, error) {
+	if err := validateBlobContainerURL(containerURL); err != nil {
+		return nil, err
+	}
+
+	// The options are copied so the blob pipeline can't change the ARM options shared by the run's other clients.
+	clientOptions := &policy.ClientOptions{}
+	if options != nil {
+		co := options.ClientOptions
+		clientOptions = &co
+	}
+
+	pipeline := runtime.NewPipeline(armModuleName, armModuleVersion, runtime.PipelineOptions{
+		PerRetry: []policy.Policy{
+			runtime.NewBearerTokenPolicy(cred, []string{"https://storage.azure.com/.default"}, nil),
+		},
+	}, clientOptions)
+
+	return &BlobSink{
+		containerURL: strings.TrimSuffix(containerURL, "/"),
+		runID:        runID,
+		pipeline:     pipeline,
+	}, nil
+}
+
+func (s *BlobSink) Name() string {
+	return SinkBlob
+}
+
+func (s *BlobSink) WriteEvidence(ctx context.Context, batch *EvidenceBatch) error {
+	if len(batch.Evidence) == 0 {
+		return nil
+	}
+
+	body := bytes.Buffer{}
+	for _, evidence := range batch.Evidence {
+		line, err := protojson.Marshal(evidence)
+		if err != nil {
+			return err
+		}
+		body.Write(line)
+		body.WriteByte('\n')
+	}
+	return s.upload(ctx, fmt.Sprintf("%s/evidence-%d.ndjson", s.runID, batch.Sequence), body.Bytes())
+}
+
+func (s *BlobSink) WriteServerData(ctx context.Context, data *ServerData) error {
+	line, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return s.upload(ctx, fmt.Sprintf("%s/data-%d.ndjson", s.runID, s.dataSequence.Add(1)), append(line, '\n'))
+}
+
+// upload puts the blob, replacing any blob of the same name.
+func (s *BlobSink) upload(ctx context.Context, blobName string, body []byte) error {
+	req, err := runtime.NewRequest(ctx, http.MethodPut, runtime.JoinPaths(s.containerURL, blobName))
+	if err != nil {
+		return err
+	}
+	req.Raw().Header.Set("x-ms-blob-type", "BlockBlob")
+	req.Raw().Header.Set("x-ms-version", "2021-08-06")
+	if err := req.SetBody(streaming.NopCloser(bytes.NewReader(body)), "application/x-ndjson"); err != nil {
+		return err
+	}
+
+	resp, err := s.pipeline.Do(req)
+	if err != nil {
+		return err
+	}
+	if !runtime.HasStatusCode(resp, http.StatusCreated) {
+		return runtime.NewResponseError(resp)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateSinkConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  map[string]string
+		wantErr string
+	}{
+		{"default", map[string]string{}, ""},
+		{"api and file", map[string]string{"sink": "API, file", "output_dir": "/tmp/evidence"}, ""},
+		{"blob", map[string]string{"sink": "blob", "blob_container_url": "https://account.blob.core.windows.net/evidence"}, ""},
+		{"unknown sink", map[string]string{"sink": "api,s3"}, `unknown sink "s3"`},
+		{"blob without a container", map[string]string{"sink": "blob"}, "blob_container_url is required for the blob sink"},
+		{"blob with an invalid container", map[string]string{"sink": "blob", "blob_container_url": "evidence"}, "blob_container_url is not a valid URL"},
+		{"file without a directory", map[string]string{"sink": "file"}, "output_dir is required for the file sink"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := validateSinkConfig(tt.config)
+			if tt.wantErr == "" {
+				if len(errs) != 0 {
+					t.Errorf("validateSinkConfig() = %v, want no errors", errs)
+				}
+				return
+			}
+			if len(errs) != 1 || !strings.Contains(errs[0].Error(), tt.wantErr) {
+				t.Errorf("validateSinkConfig() = %v, want an error containing %q", errs, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateConfigRejectsSinkConfig(t *testing.T) {
+	config := map[string]string{
+		"subscription_id": "00000000-0000-0000-0000-000000000001",
+		"sink":            "blob",
+	}
+	if err := ValidateConfig(config); err == nil || !strings.Contains(err.Error(), "blob_container_url is required") {
+		t.Errorf("ValidateConfig() = %v, want the missing blob_container_url", err)
+	}
+
+	// A dry run builds no sinks, so it doesn't need their settings.
+	config["dry_run"] = "true"
+	if err := ValidateConfig(config); err != nil {
+		t.Errorf("ValidateConfig(dry run) = %v, want no error", err)
+	}
+}
@@ -0,0 +1,111 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/postgresql/armpostgresqlflexibleservers"
+)
+
+// defaultStorageTierRules pairs SKU tiers with the storage performance tiers and types considered a mismatch for them:
+// high performance storage on burstable compute, and the smallest storage tiers on memory optimized compute.
+const defaultStorageTierRules = "Burstable=P40|P50|P60|P70|P80|PremiumV2_LRS,MemoryOptimized=P1|P2|P3|P4"
+
+// StorageTierRules maps a lower case SKU tier to the lower case storage tiers or storage types that are mismatched with it.
+type StorageTierRules map[string][]string
+
+// ParseStorageTierRules parses comma separated <sku-tier>=<storage>|<storage> rules, where each storage entry is
+// matched against both the storage performance tier (e.g. P30) and the storage type (e.g. PremiumV2_LRS).
+func ParseStorageTierRules(raw string) (StorageTierRules, error) {
+	rules := StorageTierRules{}
+
+	for _, rule := range strings.Split(raw, ",") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+
+		skuTier, storage, found := strings.Cut(rule, "=")
+		skuTier = strings.ToLower(strings.TrimSpace(skuTier))
+		if !found || skuTier == "" {
+			return nil, fmt.Errorf("invalid storage tier rule %q, expected <sku-tier>=<storage>|<storage>", rule)
+		}
+
+		for _, entry := range strings.Split(storage, "|") {
+			if entry = strings.ToLower(strings.TrimSpace(entry)); entry != "" {
+				rules[skuTier] = append(rules[skuTier], entry)
+			}
+		}
+	}
+
+	return rules, nil
+}
+
+// Mismatch returns the storage tier or type that is mismatched with the SKU tier, or an empty string if none is.
+func (r StorageTierRules) Mismatch(skuTier string, storage *ExtendedStorage) string {
+	if storage == nil {
+		return ""
+	}
+
+	for _, disallowed := range r[strings.ToLower(skuTier)] {
+		for _, value := range []*string{storage.Tier, storage.Type} {
+			if value != nil && strings.ToLower(*value) == disallowed {
+				return *value
+			}
+		}
+	}
+	return ""
+}
+
+// storageTierMismatch evaluates the configured rules against a server, returning whether the data was available
+// and the mismatched storage tier or type, if any.
+func storageTierMismatch(rules StorageTierRules, server *armpostgresqlflexibleservers.Server, extended *ExtendedServer) (bool, string) {
+	if server.SKU == nil || server.SKU.Tier == nil || extended == nil || extended.Properties == nil || extended.Properties.Storage == nil {
+		return false, ""
+	}
+	return true, rules.Mismatch(string(*server.SKU.Tier), extended.Properties.Storage)
+}
+
+// StorageConfig is a server's storage configuration in the policy input. Fields Azure doesn't report for the server's
+// storage type are omitted, e.g. the throughput of Premium_LRS storage, which is set by its performance tier.
+type StorageConfig struct {
+	SizeGB *int32 `json:"size_gb,omitempty"`
+	// AutoGrow is Enabled or Disabled.
+	AutoGrow string `json:"auto_grow,omitempty"`
+	// Tier is the performance tier of Premium_LRS storage, e.g. P30.
+	Tier string `json:"tier,omitempty"`
+	// Type is Premium_LRS or PremiumV2_LRS.
+	Type string `json:"type,omitempty"`
+	IOPS *int32 `json:"iops,omitempty"`
+	// ThroughputMBps is the provisioned throughput in MB/s.
+	ThroughputMBps *int32 `json:"throughput_mbps,omitempty"`
+}
+
+// NewStorageConfig summarises the server's storage. The extended server may be nil when it could not be fetched, in
+// which case only the size is known.
+func NewStorageConfig(server *armpostgresqlflexibleservers.Server, extended *ExtendedServer) *StorageConfig {
+	storage := &StorageConfig{}
+	if server.Properties != nil && server.Properties.Storage != nil {
+		storage.SizeGB = server.Properties.Storage.StorageSizeGB
+	}
+
+	if extended == nil || extended.Properties == nil || extended.Properties.Storage == nil {
+		return storage
+	}
+	source := extended.Properties.Storage
+	if source.StorageSizeGB != nil {
+		storage.SizeGB = source.StorageSizeGB
+	}
+	if source.AutoGrow != nil {
+		storage.AutoGrow = *source.AutoGrow
+	}
+	if source.Tier != nil {
+		storage.Tier = *source.Tier
+	}
+	if source.Type != nil {
+		storage.Type = *source.Type
+	}
+	storage.IOPS = source.Iops
+	storage.ThroughputMBps = source.Throughput
+	return storage
+}
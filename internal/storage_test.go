@@ -0,0 +1,103 @@
+package internal
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/postgresql/armpostgresqlflexibleservers"
+)
+
+func TestParseStorageTierRules(t *testing.T) {
+	rules, err := ParseStorageTierRules(defaultStorageTierRules)
+	if err != nil {
+		t.Fatalf("ParseStorageTierRules(default): %v", err)
+	}
+	want := StorageTierRules{
+		"burstable":       {"p40", "p50", "p60", "p70", "p80", "premiumv2_lrs"},
+		"memoryoptimized": {"p1", "p2", "p3", "p4"},
+	}
+	if !reflect.DeepEqual(rules, want) {
+		t.Errorf("ParseStorageTierRules(default) = %v, want %v", rules, want)
+	}
+
+	rules, err = ParseStorageTierRules(" GeneralPurpose = P1 | | P2 ,, ")
+	if err != nil {
+		t.Fatalf("ParseStorageTierRules: %v", err)
+	}
+	if want := (StorageTierRules{"generalpurpose": {"p1", "p2"}}); !reflect.DeepEqual(rules, want) {
+		t.Errorf("ParseStorageTierRules = %v, want %v", rules, want)
+	}
+
+	for _, raw := range []string{"Burstable", "Burstable=P40,MemoryOptimized", "=P40", " =P40"} {
+		if rules, err := ParseStorageTierRules(raw); err == nil {
+			t.Errorf("ParseStorageTierRules(%q) = %v, want an error", raw, rules)
+		}
+	}
+}
+
+func TestStorageTierRulesMismatch(t *testing.T) {
+	rules, err := ParseStorageTierRules(defaultStorageTierRules)
+	if err != nil {
+		t.Fatalf("ParseStorageTierRules(default): %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		skuTier string
+		storage *ExtendedStorage
+		want    string
+	}{
+		{"mismatched tier", "Burstable", &ExtendedStorage{Tier: to.Ptr("P40"), Type: to.Ptr("Premium_LRS")}, "P40"},
+		{"mismatched type", "Burstable", &ExtendedStorage{Type: to.Ptr("PremiumV2_LRS")}, "PremiumV2_LRS"},
+		{"sku tier case", "burstable", &ExtendedStorage{Tier: to.Ptr("P50")}, "P50"},
+		{"storage case", "MemoryOptimized", &ExtendedStorage{Tier: to.Ptr("p4")}, "p4"},
+		{"matched tier", "Burstable", &ExtendedStorage{Tier: to.Ptr("P10"), Type: to.Ptr("Premium_LRS")}, ""},
+		{"sku tier without rules", "GeneralPurpose", &ExtendedStorage{Tier: to.Ptr("P80")}, ""},
+		{"no storage", "Burstable", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rules.Mismatch(tt.skuTier, tt.storage); got != tt.want {
+				t.Errorf("Mismatch(%q) = %q, want %q", tt.skuTier, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStorageTierMismatch(t *testing.T) {
+	rules, err := ParseStorageTierRules(defaultStorageTierRules)
+	if err != nil {
+		t.Fatalf("ParseStorageTierRules(default): %v", err)
+	}
+	burstable := &armpostgresqlflexibleservers.Server{
+		SKU: &armpostgresqlflexibleservers.SKU{Tier: to.Ptr(armpostgresqlflexibleservers.SKUTierBurstable)},
+	}
+	extended := &ExtendedServer{Properties: &ExtendedServerProperties{Storage: &ExtendedStorage{Tier: to.Ptr("P60")}}}
+
+	tests := []struct {
+		name         string
+		server       *armpostgresqlflexibleservers.Server
+		extended     *ExtendedServer
+		wantKnown    bool
+		wantMismatch string
+	}{
+		{"mismatch", burstable, extended, true, "P60"},
+		{"no mismatch", burstable, &ExtendedServer{Properties: &ExtendedServerProperties{Storage: &ExtendedStorage{Tier: to.Ptr("P10")}}}, true, ""},
+		{"no SKU", &armpostgresqlflexibleservers.Server{}, extended, false, ""},
+		{"no SKU tier", &armpostgresqlflexibleservers.Server{SKU: &armpostgresqlflexibleservers.SKU{}}, extended, false, ""},
+		{"no extended server", burstable, nil, false, ""},
+		{"no extended properties", burstable, &ExtendedServer{}, false, ""},
+		{"no extended storage", burstable, &ExtendedServer{Properties: &ExtendedServerProperties{}}, false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			known, mismatch := storageTierMismatch(rules, tt.server, tt.extended)
+			if known != tt.wantKnown || mismatch != tt.wantMismatch {
+				t.Errorf("storageTierMismatch() = (%v, %q), want (%v, %q)", known, mismatch, tt.wantKnown, tt.wantMismatch)
+			}
+		})
+	}
+}
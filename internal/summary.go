@@ -0,0 +1,74 @@
+package internal
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+// RunSummary is the structured outcome of a run, so operators can tell a run that failed outright from one that
+// only failed for some subscriptions.
+type RunSummary struct {
+	Subscriptions     int   `json:"subscriptions"`
+	ServersListed     int64 `json:"servers_listed"`
+	ServersCollected  int64 `json:"servers_collected"`
+	ServersEvaluated  int64 `json:"servers_evaluated"`
+	ServersSkipped    int64 `json:"servers_skipped"`
+	EvidenceSubmitted int64 `json:"evidence_submitted"`
+	// Errors counts the run's errors by subscription and then by category. Errors that aren't specific to one
+	// subscription are recorded against every subscription in the run, comma separated.
+	Errors map[string]map[string]int `json:"errors"`
+	// EvidenceSubmittedBySink counts the evidence each sink wrote, keyed by sink name. Unlike EvidenceSubmitted, which
+	// only counts evidence written to every sink, one failing sink doesn't hide the evidence the others wrote.
+	EvidenceSubmittedBySink map[string]int64 `json:"evidence_submitted_by_sink"`
+}
+
+func NewRunSummary(subscriptionIDs []string, metrics *runMetrics, report []ErrorRecord) *RunSummary {
+	summary := &RunSummary{
+		Subscriptions:     len(subscriptionIDs),
+		ServersListed:     metrics.listed.Load(),
+		ServersCollected:  metrics.collected.Load(),
+		ServersEvaluated:  metrics.evaluated.Load(),
+		ServersSkipped:    metrics.skipped.Load(),
+		EvidenceSubmitted: metrics.submitted.Load(),
+		Errors:            map[string]map[string]int{},
+	}
+	summary.EvidenceSubmittedBySink = metrics.submittedBySinks()
+
+	for _, record := range report {
+		subscription := record.Scope
+		if parsed, err := ParseResourceID(record.Scope); err == nil && parsed.SubscriptionID() != "" {
+			subscription = parsed.SubscriptionID()
+		}
+		subscription = strings.ToLower(subscription)
+
+		if summary.Errors[subscription] == nil {
+			summary.Errors[subscription] = map[string]int{}
+		}
+		summary.Errors[subscription][record.Category]++
+	}
+	return summary
+}
+
+// Log writes the summary as a single info line.
+func (s *RunSummary) Log(logger hclog.Logger) {
+	summaryJSON, err := json.Marshal(s)
+	if err != nil {
+		logger.Error("Error encoding run summary", "error", err)
+		return
+	}
+	logger.Info("Azure PostgreSQL run summary",
+		"subscriptions", s.Subscriptions,
+		"servers_collected", s.ServersCollected,
+		"servers_evaluated", s.ServersEvaluated,
+		"servers_skipped", s.ServersSkipped,
+		"evidence_submitted", s.EvidenceSubmitted,
+		"summary", string(summaryJSON),
+	)
+}
+
+// Summary returns the summary of the last call to Process, or nil when it returned before assessing any server.
+func (dp *AzureDataProcessor) Summary() *RunSummary {
+	return dp.summary
+}
@@ -0,0 +1,126 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+)
+
+// defaultEnvironmentTag is the tag classifying a server's environment, e.g. production or dev, unless environment_tag
+// is configured.
+const defaultEnvironmentTag = "environment"
+
+// TagSelector matches servers on their Azure tags. Tag keys are matched case-insensitively, as Azure treats them,
+// while values must match exactly.
+type TagSelector struct {
+	requirements []tagRequirement
+}
+
+type tagRequirement struct {
+	key    string
+	values []string
+}
+
+// ParseTagSelector parses a selector of comma separated key=value requirements, all of which must match. A
+// requirement may accept several values separated by |, e.g. review-window=2024-Q1|2024-Q2.
+// An empty selector matches every server.
+func ParseTagSelector(selector string) (*TagSelector, error) {
+	tagSelector := &TagSelector{}
+
+	for _, requirement := range strings.Split(selector, ",") {
+		requirement = strings.TrimSpace(requirement)
+		if requirement == "" {
+			continue
+		}
+
+		key, value, found := strings.Cut(requirement, "=")
+		key = strings.TrimSpace(key)
+		if !found || key == "" {
+			return nil, fmt.Errorf("invalid tag requirement %q, expected key=value", requirement)
+		}
+
+		values := make([]string, 0)
+		for _, v := range strings.Split(value, "|") {
+			values = append(values, strings.TrimSpace(v))
+		}
+
+		tagSelector.requirements = append(tagSelector.requirements, tagRequirement{
+			key:    key,
+			values: values,
+		})
+	}
+
+	return tagSelector, nil
+}
+
+// Matches reports whether the tags satisfy every requirement of the selector.
+func (s *TagSelector) Matches(tags map[string]*string) bool {
+	for _, requirement := range s.requirements {
+		value, ok := lookupTag(tags, requirement.key)
+		if !ok || !containsString(requirement.values, value) {
+			return false
+		}
+	}
+	return true
+}
+
+// Empty reports whether the selector has no requirements.
+func (s *TagSelector) Empty() bool {
+	return len(s.requirements) == 0
+}
+
+// NormaliseTags lower-cases tag keys, as Azure treats them case-insensitively, so policies can look tags up by a
+// single spelling. Tags without a value map to an empty string.
+func NormaliseTags(tags map[string]*string) map[string]string {
+	normalised := make(map[string]string, len(tags))
+	for key, value := range tags {
+		normalised[strings.ToLower(key)] = ""
+		if value != nil {
+			normalised[strings.ToLower(key)] = *value
+		}
+	}
+	return normalised
+}
+
+// TagLabels returns the labels for the normalised tags named in keys, or for every tag when keys contains *. Labels
+// are prefixed with tag- so they can't collide with the plugin's own labels.
+func TagLabels(tags map[string]string, keys []string) map[string]string {
+	labels := map[string]string{}
+	for key, value := range tags {
+		if containsString(keys, "*") || containsString(keys, key) {
+			labels["tag-"+key] = value
+		}
+	}
+	return labels
+}
+
+// MissingTags returns the required tag keys the server doesn't carry, in the order they were configured.
+func MissingTags(tags map[string]string, required []string) []string {
+	missing := make([]string, 0)
+	for _, key := range required {
+		if _, ok := tags[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	return missing
+}
+
+func lookupTag(tags map[string]*string, key string) (string, bool) {
+	for tagKey, tagValue := range tags {
+		if strings.EqualFold(tagKey, key) {
+			if tagValue == nil {
+				return "", true
+			}
+			return *tagValue, true
+		}
+	}
+	return "", false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
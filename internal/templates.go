@@ -0,0 +1,66 @@
+package internal
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/compliance-framework/agent/runner/proto"
+)
+
+var templatePlaceholder = regexp.MustCompile(`\{([A-Za-z0-9_-]+)\}`)
+
+// evidenceTemplatePlaceholders maps each supported placeholder to the evidence label it is read from.
+var evidenceTemplatePlaceholders = map[string]string{
+	"name":           "name",
+	"resource-group": "resource-group",
+	"location":       "location",
+	"policy":         "_policy",
+}
+
+// EvidenceTemplates rewrite evidence titles and descriptions from configured templates. An unset template leaves
+// the title or description produced by the policy untouched.
+type EvidenceTemplates struct {
+	title       string
+	description string
+}
+
+func NewEvidenceTemplates(config map[string]string) (*EvidenceTemplates, error) {
+	templates := &EvidenceTemplates{
+		title:       config["evidence_title_template"],
+		description: config["evidence_description_template"],
+	}
+
+	if err := validateTemplate("evidence_title_template", templates.title); err != nil {
+		return nil, err
+	}
+	if err := validateTemplate("evidence_description_template", templates.description); err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+func validateTemplate(key string, template string) error {
+	for _, match := range templatePlaceholder.FindAllStringSubmatch(template, -1) {
+		if _, ok := evidenceTemplatePlaceholders[match[1]]; !ok {
+			return fmt.Errorf("%s contains unknown placeholder {%s}", key, match[1])
+		}
+	}
+	return nil
+}
+
+// Apply renders the templates for an evidence item using its labels.
+func (t *EvidenceTemplates) Apply(evidence *proto.Evidence) {
+	if t.title != "" {
+		evidence.Title = renderTemplate(t.title, evidence.Labels)
+	}
+	if t.description != "" {
+		evidence.Description = StringAddressed(renderTemplate(t.description, evidence.Labels))
+	}
+}
+
+func renderTemplate(template string, labels map[string]string) string {
+	return templatePlaceholder.ReplaceAllStringFunc(template, func(placeholder string) string {
+		name := placeholder[1 : len(placeholder)-1]
+		return labels[evidenceTemplatePlaceholders[name]]
+	})
+}
@@ -0,0 +1,35 @@
+package internal
+
+const subscriptionsAPIVersion = "2022-12-01"
+
+type armSubscription struct {
+	TenantID *string `json:"tenantId"`
+}
+
+// GetTenantID resolves the tenant that owns a subscription. Results, including failures, are cached for the
+// rest of the run, and an empty string is returned when the tenant can't be determined. The lock is held while
+// fetching, so concurrent workers look each subscription up only once.
+func (dp *AzureDataProcessor) GetTenantID(subscriptionID string) string {
+	dp.tenantIDsMu.Lock()
+	defer dp.tenantIDsMu.Unlock()
+
+	if tenantID, ok := dp.tenantIDs[subscriptionID]; ok {
+		return tenantID
+	}
+
+	tenantID := ""
+	client, err := dp.getARMClient()
+	if err == nil {
+		subscription := &armSubscription{}
+		err = client.Get(dp.ctx, "/subscriptions/"+subscriptionID, subscriptionsAPIVersion, subscription)
+		if err == nil && subscription.TenantID != nil {
+			tenantID = *subscription.TenantID
+		}
+	}
+	if tenantID == "" {
+		dp.logger.Warn("unable to determine the tenant of the subscription, omitting the tenant-id label", "subscription", subscriptionID, "error", err)
+	}
+
+	dp.tenantIDs[subscriptionID] = tenantID
+	return tenantID
+}
@@ -0,0 +1,48 @@
+package internal
+
+import (
+	"strings"
+	"time"
+)
+
+// ThreatProtection is a server's Microsoft Defender advanced threat protection setting.
+type ThreatProtection struct {
+	// State is Enabled or Disabled.
+	State        string     `json:"state"`
+	CreationTime *time.Time `json:"creation_time,omitempty"`
+}
+
+type armThreatProtectionSettings struct {
+	Properties *struct {
+		State        *string    `json:"state"`
+		CreationTime *time.Time `json:"creationTime"`
+	} `json:"properties"`
+}
+
+// GetThreatProtection fetches the server's advanced threat protection setting. Every flexible server has a single
+// setting named Default.
+func (dp *AzureDataProcessor) GetThreatProtection(serverID string) (*ThreatProtection, error) {
+	client, err := dp.getARMClient()
+	if err != nil {
+		return nil, err
+	}
+
+	settings := &armThreatProtectionSettings{}
+	if err := client.Get(dp.ctx, serverID+"/advancedThreatProtectionSettings/Default", flexibleServersAPIVersion, settings); err != nil {
+		return nil, err
+	}
+
+	protection := &ThreatProtection{}
+	if settings.Properties != nil {
+		if settings.Properties.State != nil {
+			protection.State = *settings.Properties.State
+		}
+		protection.CreationTime = settings.Properties.CreationTime
+	}
+	return protection, nil
+}
+
+// Enabled reports whether threat protection is enabled.
+func (p *ThreatProtection) Enabled() bool {
+	return strings.EqualFold(p.State, "Enabled")
+}
@@ -2,13 +2,19 @@ package internal
 
 import (
 	"errors"
-	"strings"
+	"fmt"
+	"sort"
 )
 
 func StringAddressed(str string) *string {
 	return &str
 }
 
+func BoolAddressed(b bool) *bool {
+	return &b
+}
+
+// MergeMaps merges the maps into a new map. A key defined by more than one map takes its value from the last of them.
 func MergeMaps(maps ...map[string]string) map[string]string {
 	result := make(map[string]string)
 	for _, imap := range maps {
@@ -19,26 +25,50 @@ func MergeMaps(maps ...map[string]string) map[string]string {
 	return result
 }
 
-func ParseAzureResourceID(resourceID string) (map[string]string, error) {
-	if resourceID == "" {
-		return nil, errors.New("resourceID cannot be empty")
-	}
+// MergeMapsStrict merges the maps into a new map like MergeMaps, but returns an error naming every key that more than
+// one map defines with different values, e.g. a computed label colliding with an Azure tag of the same name.
+// A key repeated with the same value is not a conflict.
+func MergeMapsStrict(maps ...map[string]string) (map[string]string, error) {
+	result := make(map[string]string)
+	var errs []error
+	for _, imap := range maps {
+		// Keys are visited in order so the reported conflicts are stable between runs.
+		keys := make([]string, 0, len(imap))
+		for k := range imap {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
 
-	parts := strings.Split(strings.Trim(resourceID, "/"), "/")
-	if len(parts)%2 != 0 {
-		return nil, errors.New("invalid Azure resource ID format")
+		for _, k := range keys {
+			v := imap[k]
+			if existing, ok := result[k]; ok && existing != v {
+				errs = append(errs, fmt.Errorf("conflicting values for key %q: %q and %q", k, existing, v))
+				continue
+			}
+			result[k] = v
+		}
 	}
-
-	result := make(map[string]string)
-	for i := 0; i < len(parts)-1; i += 2 {
-		result[parts[i]] = parts[i+1]
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
 	}
 	return result, nil
 }
 
-// normaliseLocation converts a location string to a lower case and all spaces removed.
-// This is useful for ensuring consistent formatting of location strings between different services that report on a different format.
-// For example, "UK South" and "uksouth" should be treated the same.
-func normaliseLocation(location string) string {
-	return strings.ToLower(strings.ReplaceAll(location, " ", ""))
+// ParseAzureResourceID parses the ID of a resource within a resource group, such as a server, returning an error
+// naming the missing segment when the ID has no subscription, resource group or resource name.
+func ParseAzureResourceID(resourceID string) (*ResourceID, error) {
+	parsed, err := ParseResourceID(resourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case parsed.SubscriptionID() == "":
+		return nil, fmt.Errorf("invalid Azure resource ID %q: missing subscriptions segment", resourceID)
+	case parsed.ResourceGroup() == "":
+		return nil, fmt.Errorf("invalid Azure resource ID %q: missing resourceGroups segment", resourceID)
+	case parsed.Name == "" || parsed.Provider == "":
+		return nil, fmt.Errorf("invalid Azure resource ID %q: missing resource provider, type or name", resourceID)
+	}
+	return parsed, nil
 }
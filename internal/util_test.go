@@ -0,0 +1,78 @@
+package internal
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseAzureResourceIDCasing(t *testing.T) {
+	// The SDK returns mixed case segment names, while other APIs, such as Resource Graph and the activity log,
+	// lower case them.
+	for _, id := range []string{
+		"/subscriptions/00000000-0000-0000-0000-000000000001/resourceGroups/RG/providers/Microsoft.DBforPostgreSQL/flexibleServers/x",
+		"/subscriptions/00000000-0000-0000-0000-000000000001/resourcegroups/RG/providers/microsoft.dbforpostgresql/flexibleservers/x",
+		"/SUBSCRIPTIONS/00000000-0000-0000-0000-000000000001/RESOURCEGROUPS/RG/PROVIDERS/Microsoft.DBforPostgreSQL/FLEXIBLESERVERS/x",
+	} {
+		parsed, err := ParseAzureResourceID(id)
+		if err != nil {
+			t.Errorf("ParseAzureResourceID(%q): %v", id, err)
+			continue
+		}
+		if got := parsed.SubscriptionID(); got != "00000000-0000-0000-0000-000000000001" {
+			t.Errorf("ParseAzureResourceID(%q).SubscriptionID() = %q", id, got)
+		}
+		if got := parsed.ResourceGroup(); got != "RG" {
+			t.Errorf("ParseAzureResourceID(%q).ResourceGroup() = %q", id, got)
+		}
+		if parsed.Name != "x" {
+			t.Errorf("ParseAzureResourceID(%q).Name = %q", id, parsed.Name)
+		}
+		if got := parsed.Segment("flexibleServers"); got != "x" {
+			t.Errorf("ParseAzureResourceID(%q).Segment(flexibleServers) = %q", id, got)
+		}
+	}
+}
+
+func TestParseAzureResourceIDMissingSegments(t *testing.T) {
+	for _, id := range []string{
+		"/subscriptions/sub-a",
+		"/subscriptions/sub-a/resourceGroups/RG",
+		"/subscriptions/sub-a/providers/Microsoft.DBforPostgreSQL/flexibleServers/x",
+		"/resourceGroups/RG/providers/Microsoft.DBforPostgreSQL/flexibleServers/x",
+		"/subscriptions/sub-a/resourceGroups/RG/providers/Microsoft.DBforPostgreSQL/flexibleServers",
+	} {
+		if parsed, err := ParseAzureResourceID(id); err == nil {
+			t.Errorf("ParseAzureResourceID(%q) = %+v, want an error", id, parsed)
+		}
+	}
+}
+
+func TestMergeMapsStrict(t *testing.T) {
+	merged, err := MergeMapsStrict(map[string]string{"_policy": "builtin_ssl"}, map[string]string{"provider": "azure", "type": "database"})
+	if err != nil {
+		t.Fatalf("MergeMapsStrict(disjoint): %v", err)
+	}
+	if want := map[string]string{"_policy": "builtin_ssl", "provider": "azure", "type": "database"}; !reflect.DeepEqual(merged, want) {
+		t.Errorf("MergeMapsStrict(disjoint) = %v, want %v", merged, want)
+	}
+
+	merged, err = MergeMapsStrict(map[string]string{"provider": "azure"}, map[string]string{"provider": "azure", "type": "database"})
+	if err != nil {
+		t.Fatalf("MergeMapsStrict(repeated value): %v", err)
+	}
+	if want := map[string]string{"provider": "azure", "type": "database"}; !reflect.DeepEqual(merged, want) {
+		t.Errorf("MergeMapsStrict(repeated value) = %v, want %v", merged, want)
+	}
+
+	merged, err = MergeMapsStrict(map[string]string{"_policy": "builtin_ssl", "type": "database"}, map[string]string{"_policy": "from-tag", "type": "database"})
+	if err == nil {
+		t.Fatalf("MergeMapsStrict(conflict) = %v, want an error", merged)
+	}
+	if !strings.Contains(err.Error(), `"_policy"`) {
+		t.Errorf("MergeMapsStrict(conflict) error %q doesn't name the conflicting key", err)
+	}
+	if strings.Contains(err.Error(), `"type"`) {
+		t.Errorf("MergeMapsStrict(conflict) error %q names a key repeated with the same value", err)
+	}
+}
@@ -0,0 +1,110 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+var subscriptionIDPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// ValidateConfig checks the plugin configuration up front, returning every missing or malformed key at once rather
+// than failing part way through a run.
+func ValidateConfig(config map[string]string) error {
+	var errs []error
+
+	subscriptionKey := "subscription_id"
+	subscriptionIDs := ConfigList(config, "subscription_ids", nil)
+	if len(subscriptionIDs) > 0 {
+		subscriptionKey = "subscription_ids"
+	} else {
+		subscriptionIDs = ConfigList(config, "subscription_id", nil)
+	}
+	if len(subscriptionIDs) == 0 {
+		errs = append(errs, errors.New("missing required config key subscription_id"))
+	}
+	for _, subscriptionID := range subscriptionIDs {
+		if !subscriptionIDPattern.MatchString(subscriptionID) {
+			errs = append(errs, fmt.Errorf("%s: %q is not a valid subscription ID", subscriptionKey, subscriptionID))
+		}
+	}
+
+	if _, err := ParseCloud(config); err != nil {
+		errs = append(errs, err)
+	}
+
+	switch method := ConfigString(config, "auth_method", AuthMethodDefault); method {
+	case AuthMethodDefault, AuthMethodManagedIdentity:
+	case AuthMethodClientSecret:
+		for _, key := range []string{"tenant_id", "client_id", "client_secret"} {
+			if err := requireConfig(config, key); err != nil {
+				errs = append(errs, fmt.Errorf("auth_method %s: %w", method, err))
+			}
+		}
+	default:
+		errs = append(errs, fmt.Errorf("unsupported auth_method %q, expected one of %s, %s or %s", method, AuthMethodDefault, AuthMethodClientSecret, AuthMethodManagedIdentity))
+	}
+
+	if _, err := NewTransport(config); err != nil {
+		errs = append(errs, err)
+	}
+
+	for _, limit := range []struct {
+		key     string
+		minimum int
+	}{
+		{"concurrency", 1},
+		{"policy_concurrency", 1},
+		{"evidence_batch_size", 1},
+		{"max_retries", 0},
+		{"timeout_seconds", 0},
+		{"activity_log_days", 0},
+		{"stalled_migration_days", 1},
+		{"db_timeout_seconds", 1},
+		{"db_probe_max_rows", 1},
+	} {
+		value, err := ConfigInt(config, limit.key, limit.minimum)
+		if err != nil {
+			errs = append(errs, err)
+		} else if value < limit.minimum {
+			errs = append(errs, fmt.Errorf("%s must be at least %d", limit.key, limit.minimum))
+		}
+	}
+
+	if days, err := ConfigInt(config, "activity_log_days", 0); err == nil && days > maxActivityLogDays {
+		errs = append(errs, fmt.Errorf("activity_log_days must be at most %d, as the Activity Log retains %d days of events", maxActivityLogDays, maxActivityLogDays))
+	}
+
+	for _, key := range []string{"tag_filter", "tag_window_filter"} {
+		if _, err := ParseTagSelector(config[key]); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+		}
+	}
+
+	if _, err := ParseNameFilter(config["server_name_include"], config["server_name_exclude"]); err != nil {
+		errs = append(errs, err)
+	}
+
+	if _, err := ParseStorageTierRules(ConfigString(config, "storage_tier_rules", defaultStorageTierRules)); err != nil {
+		errs = append(errs, fmt.Errorf("storage_tier_rules: %w", err))
+	}
+	if _, err := ParseMaintenanceSchedule(config); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := NewLabelSanitizer(config); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := NewEvidenceTemplates(config); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := ParseControlMappings(config); err != nil {
+		errs = append(errs, err)
+	}
+	errs = append(errs, validateDBConfig(config)...)
+	// A dry run builds none of the configured sinks, so their settings only matter otherwise.
+	if !ConfigBool(config, "dry_run") {
+		errs = append(errs, validateSinkConfig(config)...)
+	}
+
+	return errors.Join(errs...)
+}
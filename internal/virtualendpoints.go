@@ -0,0 +1,68 @@
+package internal
+
+// VirtualEndpoint is a virtual endpoint giving clients a stable reader or writer name that follows the server's
+// replicas through a failover or promotion.
+type VirtualEndpoint struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// EndpointType is ReadWrite, the only type Azure supports.
+	EndpointType string `json:"endpoint_type,omitempty"`
+	// Members are the names of the servers the endpoint's names resolve to.
+	Members []string `json:"members"`
+	// Endpoints are the endpoint's host names, the writer followed by the reader.
+	Endpoints []string `json:"endpoints"`
+}
+
+type armVirtualEndpoint struct {
+	ID         *string `json:"id"`
+	Name       *string `json:"name"`
+	Properties *struct {
+		EndpointType     *string   `json:"endpointType"`
+		Members          []*string `json:"members"`
+		VirtualEndpoints []*string `json:"virtualEndpoints"`
+	} `json:"properties"`
+}
+
+// GetVirtualEndpoints lists the virtual endpoints of a server. A server without virtual endpoints returns an empty
+// list.
+func (dp *AzureDataProcessor) GetVirtualEndpoints(serverID string) ([]VirtualEndpoint, error) {
+	client, err := dp.getARMClient()
+	if err != nil {
+		return nil, err
+	}
+
+	endpoints := make([]VirtualEndpoint, 0)
+	for endpoint, err := range ListARMResources[armVirtualEndpoint](dp.ctx, client, serverID+"/virtualendpoints", flexibleServersAPIVersion) {
+		if err != nil {
+			return nil, err
+		}
+
+		e := VirtualEndpoint{
+			Members:   make([]string, 0),
+			Endpoints: make([]string, 0),
+		}
+		if endpoint.ID != nil {
+			e.ID = *endpoint.ID
+		}
+		if endpoint.Name != nil {
+			e.Name = *endpoint.Name
+		}
+		if properties := endpoint.Properties; properties != nil {
+			if properties.EndpointType != nil {
+				e.EndpointType = *properties.EndpointType
+			}
+			for _, member := range properties.Members {
+				if member != nil {
+					e.Members = append(e.Members, *member)
+				}
+			}
+			for _, name := range properties.VirtualEndpoints {
+				if name != nil {
+					e.Endpoints = append(e.Endpoints, *name)
+				}
+			}
+		}
+		endpoints = append(endpoints, e)
+	}
+	return endpoints, nil
+}
@@ -0,0 +1,87 @@
+package internal
+
+import (
+	"strings"
+)
+
+const workspacesAPIVersion = "2022-10-01"
+
+// LogAnalyticsWorkspace is the Log Analytics workspace a diagnostic setting forwards to.
+type LogAnalyticsWorkspace struct {
+	ID string `json:"id"`
+	// CustomerID is the workspace ID used by agents and queries, as opposed to its resource ID.
+	CustomerID string `json:"customer_id,omitempty"`
+	Location   string `json:"location,omitempty"`
+	// SKU is e.g. PerGB2018 or CapacityReservation.
+	SKU           string `json:"sku,omitempty"`
+	RetentionDays *int32 `json:"retention_days,omitempty"`
+	// DailyQuotaGB is the daily ingestion cap, where -1 means no cap.
+	DailyQuotaGB *float64 `json:"daily_quota_gb,omitempty"`
+}
+
+type armWorkspace struct {
+	Location   *string `json:"location"`
+	Properties *struct {
+		CustomerID *string `json:"customerId"`
+		SKU        *struct {
+			Name *string `json:"name"`
+		} `json:"sku"`
+		RetentionInDays  *int32 `json:"retentionInDays"`
+		WorkspaceCapping *struct {
+			DailyQuotaGb *float64 `json:"dailyQuotaGb"`
+		} `json:"workspaceCapping"`
+	} `json:"properties"`
+}
+
+type workspaceResult struct {
+	workspace *LogAnalyticsWorkspace
+	err       error
+}
+
+// GetLogAnalyticsWorkspace resolves a Log Analytics workspace by its resource ID. Servers usually share a handful of
+// workspaces, so results, including failures, are cached for the rest of the run. The lock is held while fetching,
+// so concurrent workers look each workspace up only once.
+func (dp *AzureDataProcessor) GetLogAnalyticsWorkspace(workspaceID string) (*LogAnalyticsWorkspace, error) {
+	dp.workspacesMu.Lock()
+	defer dp.workspacesMu.Unlock()
+
+	// Resource IDs are case-insensitive, and diagnostic settings don't always preserve the workspace's casing.
+	key := strings.ToLower(workspaceID)
+	if result, ok := dp.workspaces[key]; ok {
+		return result.workspace, result.err
+	}
+
+	workspace, err := dp.getLogAnalyticsWorkspace(workspaceID)
+	dp.workspaces[key] = workspaceResult{workspace: workspace, err: err}
+	return workspace, err
+}
+
+func (dp *AzureDataProcessor) getLogAnalyticsWorkspace(workspaceID string) (*LogAnalyticsWorkspace, error) {
+	client, err := dp.getARMClient()
+	if err != nil {
+		return nil, err
+	}
+
+	resource := &armWorkspace{}
+	if err := client.Get(dp.ctx, workspaceID, workspacesAPIVersion, resource); err != nil {
+		return nil, err
+	}
+
+	workspace := &LogAnalyticsWorkspace{ID: workspaceID}
+	if resource.Location != nil {
+		workspace.Location = normaliseLocation(*resource.Location)
+	}
+	if properties := resource.Properties; properties != nil {
+		if properties.CustomerID != nil {
+			workspace.CustomerID = *properties.CustomerID
+		}
+		if properties.SKU != nil && properties.SKU.Name != nil {
+			workspace.SKU = *properties.SKU.Name
+		}
+		workspace.RetentionDays = properties.RetentionInDays
+		if properties.WorkspaceCapping != nil {
+			workspace.DailyQuotaGB = properties.WorkspaceCapping.DailyQuotaGb
+		}
+	}
+	return workspace, nil
+}
@@ -2,6 +2,8 @@ package main
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/compliance-framework/agent/runner"
 	"github.com/compliance-framework/agent/runner/proto"
@@ -11,8 +13,9 @@ import (
 )
 
 type CompliancePlugin struct {
-	logger hclog.Logger
-	config map[string]string
+	logger      hclog.Logger
+	config      map[string]string
+	credentials *internal.CredentialCache
 }
 
 type Tag struct {
@@ -22,15 +25,36 @@ type Tag struct {
 
 func (l *CompliancePlugin) Configure(req *proto.ConfigureRequest) (*proto.ConfigureResponse, error) {
 	l.config = req.GetConfig()
+
+	if err := internal.ValidateConfig(l.config); err != nil {
+		l.logger.Error("Invalid plugin configuration", "error", err)
+		return &proto.ConfigureResponse{}, fmt.Errorf("invalid plugin configuration: %w", err)
+	}
 	return &proto.ConfigureResponse{}, nil
 }
 
 func (l *CompliancePlugin) Eval(request *proto.EvalRequest, apiHelper runner.ApiHelper) (*proto.EvalResponse, error) {
-	ctx := context.TODO()
+	timeoutSeconds, err := internal.ConfigInt(l.config, "timeout_seconds", 0)
+	if err != nil {
+		return &proto.EvalResponse{
+			Status: proto.ExecutionStatus_FAILURE,
+		}, err
+	}
 
-	dataProcessor := internal.NewAzureDataProcessor(ctx, l.logger, l.config, apiHelper)
+	// The agent doesn't pass a request context, so the run is bounded by its own deadline when one is configured.
+	ctx, cancel := context.WithCancel(context.Background())
+	if timeoutSeconds > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), time.Duration(timeoutSeconds)*time.Second)
+	}
+	defer cancel()
+
+	dataProcessor := internal.NewAzureDataProcessor(ctx, l.logger, l.config, apiHelper, l.credentials)
 
 	evalStatus, err := dataProcessor.Process(request.GetPolicyPaths())
+	// The response only carries a status, so the summary is logged for operators instead.
+	if summary := dataProcessor.Summary(); summary != nil {
+		summary.Log(l.logger)
+	}
 	return &proto.EvalResponse{
 		Status: evalStatus,
 	}, err
@@ -43,7 +67,8 @@ func main() {
 	})
 
 	compliancePluginObj := &CompliancePlugin{
-		logger: logger,
+		logger:      logger,
+		credentials: internal.NewCredentialCache(),
 	}
 	// pluginMap is the map of plugins we can dispense.
 	logger.Debug("Initiating Azure Cosmos DB for PostgreSQL plugin")